@@ -1,8 +1,47 @@
 package ast
 
+import (
+	"strings"
+
+	"github.com/dianelooney/graphql/scanner"
+)
+
+// Node records where in the source a node starts, and the position just
+// after it ends
 type Node struct {
+	Start scanner.Position
+	End   scanner.Position
+
+	// Comments holds the comments between the previous token and the start
+	// of the node, including their #, when the parser keeps trivia. Nodes
+	// starting at the same token only give them to the outermost one
+	Comments []string
+}
+
+// Token is a token of the source, together with the trivia before it
+type Token struct {
+	Token  scanner.Token
+	Pos    scanner.Position
+	Lit    string
+	Trivia []scanner.Trivia
 }
 type Document struct {
+	Node
+
+	// TrailingComments holds the comments after the last definition, when
+	// the parser keeps trivia
+	TrailingComments []string
+
+	// Tokens holds every token of the source, ending with EOF, when the
+	// parser keeps trivia. Unless parsing was stopped by a limit, Source
+	// rebuilds the source from them byte for byte
+	Tokens []Token
+
+	// Definitions holds every definition in the order they appear,
+	// including the ones whose name is defined more than once. The fields
+	// below index them by name, keeping the last of each name
+	Definitions []Definition
+
 	Operation  *Operation
 	Operations map[string]Operation
 	Fragments  map[string]FragmentDef
@@ -10,6 +49,35 @@ type Document struct {
 	Schema     *Schema
 	Types      map[string]TypeDef
 	Directives map[string]DirectiveDef
+
+	// SchemaExtensions and TypeExtensions hold the extend definitions in the
+	// order they appear, until MergeExtensions applies them
+	SchemaExtensions []Schema
+	TypeExtensions   []TypeExtension
+}
+
+// Source returns the source doc was parsed from, rebuilt from doc.Tokens
+func (doc *Document) Source() string {
+	var b strings.Builder
+	for _, t := range doc.Tokens {
+		for _, trivia := range t.Trivia {
+			b.WriteString(trivia.Lit)
+		}
+		b.WriteString(t.Lit)
+	}
+	return b.String()
+}
+
+// Definition is a top level definition of a document
+// Exactly one of its fields is set
+type Definition struct {
+	Operation       *Operation
+	Fragment        *FragmentDef
+	Schema          *Schema
+	Type            *TypeDef
+	Directive       *DirectiveDef
+	SchemaExtension *Schema
+	TypeExtension   *TypeExtension
 }
 type TypeDef struct {
 	*ScalarDef
@@ -19,7 +87,74 @@ type TypeDef struct {
 	*EnumDef
 	*InputDef
 }
+
+// Name returns the name of the type being defined
+func (def TypeDef) Name() string {
+	switch {
+	case def.ScalarDef != nil:
+		return def.ScalarDef.Name
+	case def.ObjectTypeDef != nil:
+		return def.ObjectTypeDef.Name
+	case def.InterfaceDef != nil:
+		return def.InterfaceDef.Name
+	case def.UnionDef != nil:
+		return def.UnionDef.Name
+	case def.EnumDef != nil:
+		return def.EnumDef.Name
+	case def.InputDef != nil:
+		return def.InputDef.Name
+	}
+	return ""
+}
+
+// Node returns the node of the type being defined
+func (def TypeDef) Node() Node {
+	switch {
+	case def.ScalarDef != nil:
+		return def.ScalarDef.Node
+	case def.ObjectTypeDef != nil:
+		return def.ObjectTypeDef.Node
+	case def.InterfaceDef != nil:
+		return def.InterfaceDef.Node
+	case def.UnionDef != nil:
+		return def.UnionDef.Node
+	case def.EnumDef != nil:
+		return def.EnumDef.Node
+	case def.InputDef != nil:
+		return def.InputDef.Node
+	}
+	return Node{}
+}
+
+// Kind returns the keyword that starts the definition, such as "type" or "enum"
+func (def TypeDef) Kind() string {
+	switch {
+	case def.ScalarDef != nil:
+		return "scalar"
+	case def.ObjectTypeDef != nil:
+		return "type"
+	case def.InterfaceDef != nil:
+		return "interface"
+	case def.UnionDef != nil:
+		return "union"
+	case def.EnumDef != nil:
+		return "enum"
+	case def.InputDef != nil:
+		return "input"
+	}
+	return ""
+}
+
+// TypeExtension is an extend definition of a type defined elsewhere
+// Exactly one definition of its TypeDef is set, holding what the extension adds
+type TypeExtension struct {
+	Node
+
+	TypeDef
+}
 type Operation struct {
+	Node
+
 	OpType       string
 	Name         *string
 	Variables    []VariableDef
@@ -27,6 +162,8 @@ type Operation struct {
 	SelectionSet []Selection
 }
 type VariableDef struct {
+	Node
+
 	Name string
 	Type
 	DefaultValue *Value
@@ -38,22 +175,30 @@ type Selection struct {
 	*InlineFragment
 }
 type Field struct {
+	Node
+
 	Alias        *string
 	Name         string
-	Arguments    map[string]Value
+	Arguments    Arguments
 	Directives   []Directive
 	SelectionSet []Selection
 }
 type FragmentSpread struct {
+	Node
+
 	Name       string
 	Directives []Directive
 }
 type InlineFragment struct {
+	Node
+
 	Type         *string
 	Directives   []Directive
 	SelectionSet []Selection
 }
 type FragmentDef struct {
+	Node
+
 	Name         string
 	Type         string
 	Directives   []Directive
@@ -77,7 +222,30 @@ type Directive struct {
 	Name string
 	Arguments
 }
-type Arguments map[string]Value
+
+// Argument is a name and a value, as used for arguments and for the fields
+// of object values
+type Argument struct {
+	Node
+
+	Name  string
+	Value Value
+}
+
+// Arguments holds arguments or object fields in the order they appear,
+// including the ones whose name is used more than once
+type Arguments []Argument
+
+// Get returns the value of the last argument named name
+func (args Arguments) Get(name string) (Value, bool) {
+	for i := len(args) - 1; i >= 0; i-- {
+		if args[i].Name == name {
+			return args[i].Value, true
+		}
+	}
+	return Value{}, false
+}
+
 type Value struct {
 	Node
 
@@ -89,7 +257,7 @@ type Value struct {
 	IsNull   bool
 	Enum     *string
 	List     []Value
-	Object   map[string]Value
+	Object   Arguments
 }
 type ScalarDef struct {
 	Node
@@ -118,6 +286,8 @@ type FieldDef struct {
 }
 
 type Type struct {
+	Node
+
 	Name        *string
 	ListType    *Type
 	NonNullType *Type
@@ -126,10 +296,11 @@ type Type struct {
 type InterfaceDef struct {
 	Node
 
-	Description *string
-	Name        string
-	Directives  []Directive
-	Fields      []FieldDef
+	Description         *string
+	Name                string
+	ImplementsInterface []string
+	Directives          []Directive
+	Fields              []FieldDef
 }
 
 type UnionDef struct {
@@ -203,5 +374,6 @@ type DirectiveDef struct {
 	Description *string
 	Name        string
 	Arguments   []InputValueDef
+	Repeatable  bool
 	Locations   []string
 }
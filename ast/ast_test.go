@@ -0,0 +1,176 @@
+package ast_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/parser"
+)
+
+func parse(t *testing.T, src string) ast.Document {
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+	return doc
+}
+
+// describe names a node for the tests
+func describe(node interface{}) string {
+	switch n := node.(type) {
+	case *ast.Document:
+		return "document"
+	case *ast.Operation:
+		return "operation " + n.OpType
+	case *ast.VariableDef:
+		return "$" + n.Name
+	case *ast.Field:
+		return "field " + n.Name
+	case *ast.FragmentSpread:
+		return "..." + n.Name
+	case *ast.InlineFragment:
+		return "inline fragment"
+	case *ast.FragmentDef:
+		return "fragment " + n.Name
+	case *ast.ObjectTypeDef:
+		return "type " + n.Name
+	case *ast.FieldDef:
+		return "field def " + n.Name
+	case *ast.InputValueDef:
+		return "input value def " + n.Name
+	case *ast.Directive:
+		return "@" + n.Name
+	case *ast.Argument:
+		return "argument " + n.Name
+	case *ast.Value:
+		switch {
+		case n.Int != nil:
+			return fmt.Sprint(*n.Int)
+		case n.Variable != nil:
+			return "$" + *n.Variable
+		case n.List != nil:
+			return "list"
+		case n.Object != nil:
+			return "object"
+		}
+		return "value"
+	case *ast.Type:
+		if n.Name != nil {
+			return *n.Name
+		}
+		return "type"
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+func TestWalk(t *testing.T) {
+	doc := parse(t, `
+	query Q($v: [Int]) { a(x: [1, {y: $v}]) @skip(if: false) { b ...F ... { c } } }
+	type T { f(z: Int): Int }
+	`)
+
+	var events []string
+	var paths []string
+	ast.Walk(ast.Visitor{
+		Enter: func(node interface{}, path []interface{}) bool {
+			events = append(events, "enter "+describe(node))
+			if f, ok := node.(*ast.Field); ok && f.Name == "b" {
+				var names []string
+				for _, n := range path {
+					names = append(names, describe(n))
+				}
+				paths = names
+			}
+			return true
+		},
+		Leave: func(node interface{}, path []interface{}) {
+			events = append(events, "leave "+describe(node))
+		},
+	}, &doc)
+
+	expected := []string{
+		"enter document",
+		"enter operation query",
+		"enter $v", "enter type", "enter Int", "leave Int", "leave type", "leave $v",
+		"enter field a",
+		"enter argument x", "enter list",
+		"enter 1", "leave 1",
+		"enter object", "enter argument y", "enter $v", "leave $v", "leave argument y", "leave object",
+		"leave list", "leave argument x",
+		"enter @skip", "enter argument if", "enter value", "leave value", "leave argument if", "leave @skip",
+		"enter field b", "leave field b",
+		"enter ...F", "leave ...F",
+		"enter inline fragment", "enter field c", "leave field c", "leave inline fragment",
+		"leave field a",
+		"leave operation query",
+		"enter type T", "enter field def f",
+		"enter input value def z", "enter Int", "leave Int", "leave input value def z",
+		"enter Int", "leave Int",
+		"leave field def f", "leave type T",
+		"leave document",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected the walk\n%q\ngot\n%q", expected, events)
+	}
+	if expected := []string{"document", "operation query", "field a"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected the path of b to be %q, got %q", expected, paths)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	doc := parse(t, `{ a(x: 1) { b } c } fragment F on T { d }`)
+
+	var fields []string
+	ast.Inspect(&doc, func(node interface{}) bool {
+		if f, ok := node.(*ast.Field); ok {
+			fields = append(fields, f.Name)
+			return f.Name != "a"
+		}
+		return true
+	})
+	if expected := []string{"a", "c", "d"}; !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected to inspect the fields %q, skipping the children of a, got %q", expected, fields)
+	}
+
+	// The maps are walked when there are no definitions, and nodes can be
+	// modified in place
+	doc.Definitions = nil
+	ast.Inspect(&doc, func(node interface{}) bool {
+		if f, ok := node.(*ast.Field); ok {
+			f.Name += "!"
+		}
+		return true
+	})
+	if name := doc.Fragments["F"].SelectionSet[0].Field.Name; name != "d!" {
+		t.Errorf("Expected the field of F to be renamed, got %s", name)
+	}
+	if name := doc.Operation.SelectionSet[1].Field.Name; name != "c!" {
+		t.Errorf("Expected the field of the operation to be renamed, got %s", name)
+	}
+
+	var names []string
+	ast.Inspect(&doc.Operation.SelectionSet[0], func(node interface{}) bool {
+		names = append(names, describe(node))
+		return true
+	})
+	if expected := []string{"field a!", "argument x", "1", "field b!"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected to inspect a selection as %q, got %q", expected, names)
+	}
+}
+
+func TestSortedNames(t *testing.T) {
+	doc := parse(t, `type B { x: Int } scalar C type A { y: Int } query Z { x } query Y { y }`)
+	if names := ast.SortedNames(doc.Types); !reflect.DeepEqual(names, []string{"A", "B", "C"}) {
+		t.Errorf("SortedNames(doc.Types) returned %v", names)
+	}
+	if names := ast.SortedNames(doc.Operations); !reflect.DeepEqual(names, []string{"Y", "Z"}) {
+		t.Errorf("SortedNames(doc.Operations) returned %v", names)
+	}
+	if names := ast.SortedNames(doc.Fragments); len(names) != 0 {
+		t.Errorf("SortedNames(doc.Fragments) returned %v", names)
+	}
+}
@@ -0,0 +1,252 @@
+package ast
+
+import "github.com/dianelooney/graphql/gqlerror"
+
+// MergeExtensions applies the schema and type extensions of doc to the
+// definitions they extend, in the order they appear, and then clears them
+// and drops them from doc.Definitions
+//
+// The definitions are copied before they are extended, so other documents
+// sharing them are left untouched. Extensions of undefined types, of the
+// wrong kind of type, or redefining a field, value, member or root operation
+// are reported as errors and skipped
+//
+// Extending the schema of a document without a schema definition creates
+// one, whose root types are the object types named Query, Mutation and
+// Subscription unless the extensions define them
+func (doc *Document) MergeExtensions() (errs []error) {
+	errorf := func(n Node, format string, args ...interface{}) {
+		loc := gqlerror.Location{Line: n.Start.Line, Column: n.Start.UTF16Column}
+		errs = append(errs, gqlerror.ErrorfAt([]gqlerror.Location{loc}, format, args...))
+	}
+
+	originalSchema, originalTypes := doc.Schema, doc.Types
+	if len(doc.SchemaExtensions) > 0 {
+		var schema Schema
+		if doc.Schema != nil {
+			schema = *doc.Schema
+		}
+		defined := make(map[string]bool)
+		for _, def := range schema.RootOperationTypeDefs {
+			defined[def.OpType] = true
+		}
+		// cap the slices so that appending copies them
+		schema.Directives = schema.Directives[:len(schema.Directives):len(schema.Directives)]
+		schema.RootOperationTypeDefs = schema.RootOperationTypeDefs[:len(schema.RootOperationTypeDefs):len(schema.RootOperationTypeDefs)]
+		for _, ext := range doc.SchemaExtensions {
+			schema.Directives = append(schema.Directives, ext.Directives...)
+			for _, def := range ext.RootOperationTypeDefs {
+				if defined[def.OpType] {
+					errorf(def.Node, "the schema already defines a %s type", def.OpType)
+					continue
+				}
+				defined[def.OpType] = true
+				schema.RootOperationTypeDefs = append(schema.RootOperationTypeDefs, def)
+			}
+		}
+		if doc.Schema == nil {
+			// without a schema definition, the object types with the default
+			// names are the root types the extensions did not define
+			for _, def := range defaultRootTypes {
+				if t, ok := doc.Types[def.NamedType]; ok && t.ObjectTypeDef != nil && !defined[def.OpType] {
+					schema.RootOperationTypeDefs = append(schema.RootOperationTypeDefs, def)
+				}
+			}
+		}
+		doc.Schema = &schema
+	}
+
+	if len(doc.TypeExtensions) > 0 {
+		types := make(map[string]TypeDef, len(doc.Types))
+		for name, def := range doc.Types {
+			types[name] = def
+		}
+		for _, ext := range doc.TypeExtensions {
+			name := ext.Name()
+			base, ok := types[name]
+			if !ok {
+				errorf(ext.Node, "cannot extend type %s because it is not defined", name)
+				continue
+			}
+			if base.Kind() != ext.Kind() {
+				errorf(ext.Node, "cannot extend %s %s using extend %s", base.Kind(), name, ext.Kind())
+				continue
+			}
+			types[name] = extendType(base, ext, errorf)
+		}
+		doc.Types = types
+	}
+
+	doc.Definitions = mergedDefinitions(doc, originalSchema, originalTypes)
+	doc.SchemaExtensions = nil
+	doc.TypeExtensions = nil
+	return
+}
+
+// defaultRootTypes are the root types of a schema without a schema
+// definition
+var defaultRootTypes = []RootOperationTypeDef{
+	{OpType: "query", NamedType: "Query"},
+	{OpType: "mutation", NamedType: "Mutation"},
+	{OpType: "subscription", NamedType: "Subscription"},
+}
+
+// mergedDefinitions returns doc.Definitions without its extensions, and
+// with the schema and types that were extended replaced by their merged
+// definitions. originalSchema and originalTypes are the ones of doc before
+// they were extended
+//
+// A schema that was only extended takes the place of its first extension
+func mergedDefinitions(doc *Document, originalSchema *Schema, originalTypes map[string]TypeDef) []Definition {
+	if len(doc.SchemaExtensions) == 0 && len(doc.TypeExtensions) == 0 {
+		return doc.Definitions
+	}
+
+	var defs []Definition
+	hasSchema := originalSchema != nil
+	for _, def := range doc.Definitions {
+		switch {
+		case def.Schema != nil && def.Schema == originalSchema:
+			def.Schema = doc.Schema
+		case def.SchemaExtension != nil:
+			if hasSchema {
+				continue
+			}
+			def = Definition{Schema: doc.Schema}
+			hasSchema = true
+		case def.TypeExtension != nil:
+			continue
+		case def.Type != nil && *def.Type == originalTypes[def.Type.Name()]:
+			// only the definition that is kept by name gets extended
+			merged := doc.Types[def.Type.Name()]
+			def.Type = &merged
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// extendType returns a copy of base with the additions of ext
+func extendType(base TypeDef, ext TypeExtension, errorf func(n Node, format string, args ...interface{})) TypeDef {
+	switch {
+	case base.ScalarDef != nil:
+		def := *base.ScalarDef
+		def.Directives = appendDirectives(def.Directives, ext.ScalarDef.Directives)
+		return TypeDef{ScalarDef: &def}
+	case base.ObjectTypeDef != nil:
+		def := *base.ObjectTypeDef
+		def.Directives = appendDirectives(def.Directives, ext.ObjectTypeDef.Directives)
+		def.ImplementsInterface = extendInterfaces(def.Name, def.ImplementsInterface, ext.ObjectTypeDef.ImplementsInterface, ext.Node, errorf)
+		def.Fields = extendFields(def.Name, def.Fields, ext.ObjectTypeDef.Fields, errorf)
+		return TypeDef{ObjectTypeDef: &def}
+	case base.InterfaceDef != nil:
+		def := *base.InterfaceDef
+		def.Directives = appendDirectives(def.Directives, ext.InterfaceDef.Directives)
+		def.ImplementsInterface = extendInterfaces(def.Name, def.ImplementsInterface, ext.InterfaceDef.ImplementsInterface, ext.Node, errorf)
+		def.Fields = extendFields(def.Name, def.Fields, ext.InterfaceDef.Fields, errorf)
+		return TypeDef{InterfaceDef: &def}
+	case base.UnionDef != nil:
+		def := *base.UnionDef
+		def.Directives = appendDirectives(def.Directives, ext.UnionDef.Directives)
+		// cap the slices so that appending copies them
+		def.Types = def.Types[:len(def.Types):len(def.Types)]
+		for _, name := range ext.UnionDef.Types {
+			if containsString(def.Types, name) {
+				errorf(ext.Node, "union %s already includes %s", def.Name, name)
+				continue
+			}
+			def.Types = append(def.Types, name)
+		}
+		return TypeDef{UnionDef: &def}
+	case base.EnumDef != nil:
+		def := *base.EnumDef
+		def.Directives = appendDirectives(def.Directives, ext.EnumDef.Directives)
+		def.Values = def.Values[:len(def.Values):len(def.Values)]
+		for _, v := range ext.EnumDef.Values {
+			if hasEnumValue(def.Values, v.Name) {
+				errorf(v.Node, "enum value %s.%s can only be defined once", def.Name, v.Name)
+				continue
+			}
+			def.Values = append(def.Values, v)
+		}
+		return TypeDef{EnumDef: &def}
+	case base.InputDef != nil:
+		def := *base.InputDef
+		def.Directives = appendDirectives(def.Directives, ext.InputDef.Directives)
+		def.Fields = def.Fields[:len(def.Fields):len(def.Fields)]
+		for _, f := range ext.InputDef.Fields {
+			if hasInputValue(def.Fields, f.Name) {
+				errorf(f.Node, "field %s.%s can only be defined once", def.Name, f.Name)
+				continue
+			}
+			def.Fields = append(def.Fields, f)
+		}
+		return TypeDef{InputDef: &def}
+	}
+	return base
+}
+
+func extendInterfaces(typeName string, interfaces, added []string, ext Node, errorf func(n Node, format string, args ...interface{})) []string {
+	interfaces = interfaces[:len(interfaces):len(interfaces)]
+	for _, name := range added {
+		if containsString(interfaces, name) {
+			errorf(ext, "type %s already implements %s", typeName, name)
+			continue
+		}
+		interfaces = append(interfaces, name)
+	}
+	return interfaces
+}
+
+func extendFields(typeName string, fields, added []FieldDef, errorf func(n Node, format string, args ...interface{})) []FieldDef {
+	fields = fields[:len(fields):len(fields)]
+	for _, f := range added {
+		if hasField(fields, f.Name) {
+			errorf(f.Node, "field %s.%s can only be defined once", typeName, f.Name)
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// appendDirectives returns a new slice, so the directives of the base definition are left untouched
+func appendDirectives(directives, added []Directive) []Directive {
+	return append(directives[:len(directives):len(directives)], added...)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasField(fields []FieldDef, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasInputValue(values []InputValueDef, name string) bool {
+	for _, v := range values {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasEnumValue(values []EnumValueDef, name string) bool {
+	for _, v := range values {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Visitor holds the callbacks Walk calls for each node, either of which may
+// be nil
+//
+// The path holds the ancestors of the node, starting with the node Walk was
+// called with. It is reused as the walk goes on, so it must be copied to be
+// kept
+type Visitor struct {
+	// Enter is called before the children of node are walked. Returning
+	// false skips them, and Leave is not called for node
+	Enter func(node interface{}, path []interface{}) bool
+
+	// Leave is called after the children of node are walked
+	Leave func(node interface{}, path []interface{})
+}
+
+// Walk walks node and its children depth first, in the order they appear in
+// the source
+//
+// Nodes are pointers into the tree, so they can be modified in place:
+// *Document, *Operation, *VariableDef, *Field, *FragmentSpread,
+// *InlineFragment, *FragmentDef, *Schema, *RootOperationTypeDef,
+// *TypeExtension, *ScalarDef, *ObjectTypeDef, *FieldDef, *InterfaceDef,
+// *UnionDef, *EnumDef, *EnumValueDef, *InputDef, *InputValueDef,
+// *DirectiveDef, *Directive, *Argument, *Value or *Type. Walk can also be
+// called with a *Definition, *Selection or *TypeDef, which walks the node
+// set in it
+//
+// The definitions of a document are walked from Definitions. When it is
+// empty, they are walked from the maps instead, sorted by name
+func Walk(v Visitor, node interface{}) {
+	w := &walker{Visitor: v}
+	switch n := node.(type) {
+	case *Definition:
+		w.definition(n)
+	case *Selection:
+		w.selection(n)
+	case *TypeDef:
+		w.typeDef(n)
+	default:
+		w.walk(node)
+	}
+}
+
+// Inspect walks node like Walk, calling f before the children of each node.
+// Returning false skips them
+func Inspect(node interface{}, f func(node interface{}) bool) {
+	Walk(Visitor{Enter: func(node interface{}, path []interface{}) bool {
+		return f(node)
+	}}, node)
+}
+
+type walker struct {
+	Visitor
+	path []interface{}
+}
+
+func (w *walker) walk(node interface{}) {
+	if w.Enter != nil && !w.Enter(node, w.path) {
+		return
+	}
+	w.path = append(w.path, node)
+	w.children(node)
+	w.path = w.path[:len(w.path)-1]
+	if w.Leave != nil {
+		w.Leave(node, w.path)
+	}
+}
+
+func (w *walker) children(node interface{}) {
+	switch n := node.(type) {
+	case *Document:
+		w.document(n)
+	case *Operation:
+		for i := range n.Variables {
+			w.walk(&n.Variables[i])
+		}
+		w.directives(n.Directives)
+		w.selectionSet(n.SelectionSet)
+	case *VariableDef:
+		w.walk(&n.Type)
+		if n.DefaultValue != nil {
+			w.walk(n.DefaultValue)
+		}
+		w.directives(n.Directives)
+	case *Field:
+		w.arguments(n.Arguments)
+		w.directives(n.Directives)
+		w.selectionSet(n.SelectionSet)
+	case *FragmentSpread:
+		w.directives(n.Directives)
+	case *InlineFragment:
+		w.directives(n.Directives)
+		w.selectionSet(n.SelectionSet)
+	case *FragmentDef:
+		w.directives(n.Directives)
+		w.selectionSet(n.SelectionSet)
+	case *Schema:
+		w.directives(n.Directives)
+		for i := range n.RootOperationTypeDefs {
+			w.walk(&n.RootOperationTypeDefs[i])
+		}
+	case *RootOperationTypeDef:
+	case *TypeExtension:
+		w.typeDef(&n.TypeDef)
+	case *ScalarDef:
+		w.directives(n.Directives)
+	case *ObjectTypeDef:
+		w.directives(n.Directives)
+		w.fieldDefs(n.Fields)
+	case *InterfaceDef:
+		w.directives(n.Directives)
+		w.fieldDefs(n.Fields)
+	case *UnionDef:
+		w.directives(n.Directives)
+	case *EnumDef:
+		w.directives(n.Directives)
+		for i := range n.Values {
+			w.walk(&n.Values[i])
+		}
+	case *EnumValueDef:
+		w.directives(n.Directives)
+	case *InputDef:
+		w.directives(n.Directives)
+		w.inputValueDefs(n.Fields)
+	case *FieldDef:
+		w.inputValueDefs(n.Arguments)
+		w.walk(&n.Type)
+		w.directives(n.Directives)
+	case *InputValueDef:
+		w.walk(&n.Type)
+		if n.DefaultValue != nil {
+			w.walk(n.DefaultValue)
+		}
+		w.directives(n.Directives)
+	case *DirectiveDef:
+		w.inputValueDefs(n.Arguments)
+	case *Directive:
+		w.arguments(n.Arguments)
+	case *Argument:
+		w.walk(&n.Value)
+	case *Value:
+		for i := range n.List {
+			w.walk(&n.List[i])
+		}
+		w.arguments(n.Object)
+	case *Type:
+		if n.ListType != nil {
+			w.walk(n.ListType)
+		}
+		if n.NonNullType != nil {
+			w.walk(n.NonNullType)
+		}
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", node))
+	}
+}
+
+func (w *walker) document(doc *Document) {
+	if len(doc.Definitions) > 0 {
+		for i := range doc.Definitions {
+			w.definition(&doc.Definitions[i])
+		}
+		return
+	}
+
+	if doc.Schema != nil {
+		w.walk(doc.Schema)
+	}
+	for _, name := range SortedNames(doc.Directives) {
+		def := doc.Directives[name]
+		w.walk(&def)
+		doc.Directives[name] = def
+	}
+	for _, name := range SortedNames(doc.Types) {
+		def := doc.Types[name]
+		w.typeDef(&def)
+	}
+	for i := range doc.SchemaExtensions {
+		w.walk(&doc.SchemaExtensions[i])
+	}
+	for i := range doc.TypeExtensions {
+		w.walk(&doc.TypeExtensions[i])
+	}
+	if doc.Operation != nil {
+		w.walk(doc.Operation)
+	}
+	for _, name := range SortedNames(doc.Operations) {
+		op := doc.Operations[name]
+		w.walk(&op)
+		doc.Operations[name] = op
+	}
+	for _, name := range SortedNames(doc.Fragments) {
+		frag := doc.Fragments[name]
+		w.walk(&frag)
+		doc.Fragments[name] = frag
+	}
+}
+
+func (w *walker) definition(def *Definition) {
+	switch {
+	case def.Operation != nil:
+		w.walk(def.Operation)
+	case def.Fragment != nil:
+		w.walk(def.Fragment)
+	case def.Schema != nil:
+		w.walk(def.Schema)
+	case def.Type != nil:
+		w.typeDef(def.Type)
+	case def.Directive != nil:
+		w.walk(def.Directive)
+	case def.SchemaExtension != nil:
+		w.walk(def.SchemaExtension)
+	case def.TypeExtension != nil:
+		w.walk(def.TypeExtension)
+	}
+}
+
+func (w *walker) typeDef(def *TypeDef) {
+	switch {
+	case def.ScalarDef != nil:
+		w.walk(def.ScalarDef)
+	case def.ObjectTypeDef != nil:
+		w.walk(def.ObjectTypeDef)
+	case def.InterfaceDef != nil:
+		w.walk(def.InterfaceDef)
+	case def.UnionDef != nil:
+		w.walk(def.UnionDef)
+	case def.EnumDef != nil:
+		w.walk(def.EnumDef)
+	case def.InputDef != nil:
+		w.walk(def.InputDef)
+	}
+}
+
+func (w *walker) selection(s *Selection) {
+	switch {
+	case s.Field != nil:
+		w.walk(s.Field)
+	case s.FragmentSpread != nil:
+		w.walk(s.FragmentSpread)
+	case s.InlineFragment != nil:
+		w.walk(s.InlineFragment)
+	}
+}
+
+func (w *walker) selectionSet(sel []Selection) {
+	for i := range sel {
+		w.selection(&sel[i])
+	}
+}
+
+func (w *walker) directives(directives []Directive) {
+	for i := range directives {
+		w.walk(&directives[i])
+	}
+}
+
+func (w *walker) arguments(args Arguments) {
+	for i := range args {
+		w.walk(&args[i])
+	}
+}
+
+func (w *walker) fieldDefs(fields []FieldDef) {
+	for i := range fields {
+		w.walk(&fields[i])
+	}
+}
+
+func (w *walker) inputValueDefs(values []InputValueDef) {
+	for i := range values {
+		w.walk(&values[i])
+	}
+}
+
+// SortedNames returns the keys of m, a map with string keys such as
+// Document.Types, sorted
+func SortedNames(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.String())
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,230 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// CoerceVariableValues checks the variables sent along with a request
+// against the variable definitions of op, following the spec's
+// CoerceVariableValues
+//
+// inputs is usually decoded from JSON, so numbers may be float64. In the
+// coerced values Int is an int, Float a float64, String and ID a string,
+// Boolean a bool and enum values their name. Lists are []interface{},
+// wrapping single values where a list is expected, and input objects
+// map[string]interface{} with their defaults filled in. Custom scalars are
+// passed through unchanged. Variables that were not provided and have no
+// default are left out
+func CoerceVariableValues(s *schema.Schema, op *ast.Operation, inputs map[string]interface{}) (map[string]interface{}, []*gqlerror.Error) {
+	var errs []*gqlerror.Error
+	vars := make(map[string]interface{}, len(op.Variables))
+	for _, def := range op.Variables {
+		t := s.TypeFromAST(def.Type)
+		if t == nil || !schema.IsInputType(t) {
+			errs = append(errs, gqlerror.Errorf("variable \"$%s\" has invalid type \"%s\"", def.Name, schema.NamedTypeName(def.Type)))
+			continue
+		}
+
+		v, ok := inputs[def.Name]
+		if !ok {
+			if def.DefaultValue != nil {
+				coerced, err := coerceValue(valueFromAST(*def.DefaultValue, nil), t, "$"+def.Name)
+				if err != nil {
+					errs = append(errs, gqlerror.Errorf("variable \"$%s\" has an invalid default value: %v", def.Name, err))
+					continue
+				}
+				vars[def.Name] = coerced
+			} else if isNonNull(t) {
+				errs = append(errs, gqlerror.Errorf("variable \"$%s\" of required type \"%s\" was not provided", def.Name, t))
+			}
+			continue
+		}
+
+		coerced, err := coerceValue(v, t, "$"+def.Name)
+		if err != nil {
+			errs = append(errs, gqlerror.Errorf("variable \"$%s\" got an invalid value: %v", def.Name, err))
+			continue
+		}
+		vars[def.Name] = coerced
+	}
+	return vars, errs
+}
+
+// coerceValue converts v to the Go representation of input type t
+// path names the value in errors, like $input.tags.0
+func coerceValue(v interface{}, t schema.Type, path string) (interface{}, error) {
+	if nn, ok := t.(*schema.NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("%s must not be null, it is of type \"%s\"", path, t)
+		}
+		return coerceValue(v, nn.OfType, path)
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *schema.List:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			item, err := coerceValue(v, t.OfType, path)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			item, err := coerceValue(rv.Index(i).Interface(), t.OfType, path+"."+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	case *schema.InputObject:
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object of type \"%s\", found %s", path, t.Name, describe(v))
+		}
+		for name := range fields {
+			if t.Fields.Get(name) == nil {
+				return nil, fmt.Errorf("%s has a field \"%s\" that is not defined by type \"%s\"", path, name, t.Name)
+			}
+		}
+		obj := make(map[string]interface{}, len(t.Fields))
+		for _, f := range t.Fields {
+			fieldPath := path + "." + f.Name
+			fv, ok := fields[f.Name]
+			if !ok {
+				if f.DefaultValue != nil {
+					fv = valueFromAST(*f.DefaultValue, nil)
+				} else if isNonNull(f.Type) {
+					return nil, fmt.Errorf("%s of required type \"%s\" was not provided", fieldPath, f.Type)
+				} else {
+					continue
+				}
+			}
+			coerced, err := coerceValue(fv, f.Type, fieldPath)
+			if err != nil {
+				return nil, err
+			}
+			obj[f.Name] = coerced
+		}
+		return obj, nil
+	case *schema.Enum:
+		name, ok := v.(string)
+		if !ok || t.Value(name) == nil {
+			return nil, fmt.Errorf("%s must be a value of enum \"%s\", found %s", path, t.Name, describe(v))
+		}
+		return name, nil
+	case *schema.Scalar:
+		coerced, ok := coerceScalar(v, t)
+		if !ok {
+			return nil, fmt.Errorf("%s must be of type \"%s\", found %s", path, t.Name, describe(v))
+		}
+		return coerced, nil
+	}
+	return nil, fmt.Errorf("%s cannot be of non-input type \"%s\"", path, t)
+}
+
+// coerceScalar converts v to the Go representation of a built-in scalar,
+// passing the values of custom scalars through
+func coerceScalar(v interface{}, t *schema.Scalar) (interface{}, bool) {
+	switch t.Name {
+	case "Int":
+		f, ok := toFloat(v)
+		if !ok || f != math.Trunc(f) || f < math.MinInt32 || f > math.MaxInt32 {
+			return nil, false
+		}
+		return int(f), true
+	case "Float":
+		f, ok := toFloat(v)
+		if !ok || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, false
+		}
+		return f, true
+	case "String":
+		s, ok := v.(string)
+		return s, ok
+	case "Boolean":
+		b, ok := v.(bool)
+		return b, ok
+	case "ID":
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+		f, ok := toFloat(v)
+		if !ok || f != math.Trunc(f) {
+			return nil, false
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+	return v, true
+}
+
+// coerceResult converts a leaf value returned by a resolver to the response
+// value of scalar or enum type t, following the spec's CoerceResult
+//
+// Int and Float accept any Go number that fits, String and Boolean values
+// of their kind, ID strings and integers, and enums the name of one of
+// their values. Values of custom scalars, and of other types such as when
+// executing without a schema, are passed through unchanged
+func coerceResult(v interface{}, t schema.Type) (interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	switch t := t.(type) {
+	case *schema.Enum:
+		if rv.Kind() == reflect.String && t.Value(rv.String()) != nil {
+			return rv.String(), true
+		}
+		return nil, false
+	case *schema.Scalar:
+		switch t.Name {
+		case "String", "ID":
+			if rv.Kind() == reflect.String {
+				return rv.String(), true
+			}
+		case "Boolean":
+			if rv.Kind() == reflect.Bool {
+				return rv.Bool(), true
+			}
+		}
+		return coerceScalar(v, t)
+	}
+	return v, true
+}
+
+// toFloat converts any Go number, or a json.Number, to a float64
+func toFloat(v interface{}) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// describe formats a value for error messages
+func describe(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
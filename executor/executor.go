@@ -0,0 +1,484 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/resolver"
+	"github.com/dianelooney/graphql/schema"
+	"github.com/dianelooney/graphql/validation"
+)
+
+// Executor runs operations against resolvers
+//
+// The fields of a selection set, and the items of a list, are resolved in
+// parallel goroutines. The top level fields of a mutation are resolved one
+// after the other, in the order they were selected, as the spec requires.
+// Resolvers must therefore be safe for concurrent use
+type Executor struct {
+	// Schema, if set, is used to validate documents before they are executed
+	Schema *schema.Schema
+
+	// MaxConcurrency limits the number of resolvers that may run at the same
+	// time during one execution. Zero means no limit, one resolves every
+	// field serially
+	MaxConcurrency int
+}
+
+// Execute runs an operation from doc against root, without a schema
+//
+// operationName may be empty when doc contains exactly one operation.
+// variables holds the raw variable values sent along with the request.
+// root is the value the top level fields of the operation are resolved from,
+// resolver.AdaptObject turns a resolver.Object into a suitable root
+//
+// ctx is passed to every context aware resolver. Once it is done, no more
+// resolvers are called and the remaining fields resolve to errors
+func Execute(ctx context.Context, doc ast.Document, operationName string, variables map[string]interface{}, root resolver.ObjectContext) Result {
+	return (&Executor{}).Execute(ctx, doc, operationName, variables, root)
+}
+
+// Execute runs an operation from doc against root
+//
+// If ex.Schema is set, doc is validated and the variables are coerced with
+// CoerceVariableValues first. If either fails, no resolvers are called and
+// the errors are returned
+func (ex *Executor) Execute(ctx context.Context, doc ast.Document, operationName string, variables map[string]interface{}, root resolver.ObjectContext) (res Result) {
+	if ex.Schema != nil {
+		for _, err := range validation.Validate(ex.Schema, doc) {
+			res.Errors = append(res.Errors, gqlerror.Wrap(err))
+		}
+		if len(res.Errors) > 0 {
+			return
+		}
+	}
+
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		res.Errors = append(res.Errors, gqlerror.Wrap(err))
+		return
+	}
+	if op.OpType == "subscription" {
+		res.Errors = append(res.Errors, gqlerror.Errorf("subscription operations must be run with Subscribe"))
+		return
+	}
+
+	vars, errs := ex.variableValues(op, variables)
+	if len(errs) > 0 {
+		res.Errors = errs
+		return
+	}
+
+	e := ex.newExecution(ctx, doc, vars)
+	res.executed = true
+	res.Data, _ = e.executeSelectionSet(op.SelectionSet, root, e.rootType(op), nil, op.OpType == "mutation")
+	res.Errors = e.errors
+
+	return
+}
+
+// variableValues coerces the variables of a request when executing with a
+// schema, and otherwise only fills in the defaults of the operation
+func (ex *Executor) variableValues(op *ast.Operation, inputs map[string]interface{}) (map[string]interface{}, []*gqlerror.Error) {
+	if ex.Schema != nil {
+		return CoerceVariableValues(ex.Schema, op, inputs)
+	}
+
+	vars := make(map[string]interface{}, len(op.Variables))
+	for _, def := range op.Variables {
+		if v, ok := inputs[def.Name]; ok {
+			vars[def.Name] = v
+		} else if def.DefaultValue != nil {
+			vars[def.Name] = valueFromAST(*def.DefaultValue, nil)
+		}
+	}
+	return vars, nil
+}
+
+func (ex *Executor) newExecution(ctx context.Context, doc ast.Document, vars map[string]interface{}) *execution {
+	e := &execution{
+		ctx:    ctx,
+		schema: ex.Schema,
+		doc:    doc,
+		vars:   vars,
+		serial: ex.MaxConcurrency == 1,
+	}
+	if ex.MaxConcurrency > 1 {
+		e.limit = make(chan struct{}, ex.MaxConcurrency)
+	}
+	return e
+}
+
+// rootType returns the root type of op, or nil when executing without a schema
+func (e *execution) rootType(op *ast.Operation) *schema.Object {
+	if e.schema == nil {
+		return nil
+	}
+	return e.schema.RootType(op.OpType)
+}
+
+func getOperation(doc ast.Document, name string) (*ast.Operation, error) {
+	if name != "" {
+		op, ok := doc.Operations[name]
+		if !ok {
+			return nil, errors.New("unknown operation named \"" + name + "\"")
+		}
+		return &op, nil
+	}
+
+	if doc.Operation != nil && len(doc.Operations) == 0 {
+		return doc.Operation, nil
+	}
+	if doc.Operation == nil && len(doc.Operations) == 1 {
+		for _, op := range doc.Operations {
+			return &op, nil
+		}
+	}
+	if doc.Operation == nil && len(doc.Operations) == 0 {
+		return nil, errors.New("document does not contain any operations")
+	}
+	return nil, errors.New("an operation name is required when the document contains multiple operations")
+}
+
+type execution struct {
+	ctx    context.Context
+	schema *schema.Schema
+	doc    ast.Document
+	vars   map[string]interface{}
+
+	// serial disables goroutines altogether
+	serial bool
+	// limit holds a token for every resolver currently running, if there is a limit
+	limit chan struct{}
+
+	mu     sync.Mutex
+	errors []*gqlerror.Error
+}
+
+// addError records an error raised while executing field, pointing at it
+// unless the error already has locations
+func (e *execution) addError(err error, field *ast.Field, path []interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	gqlErr := *gqlerror.Wrap(err)
+	gqlErr.Path = path
+	if len(gqlErr.Locations) == 0 && field.Start.Line > 0 {
+		gqlErr.Locations = []gqlerror.Location{{Line: field.Start.Line, Column: field.Start.UTF16Column}}
+	}
+	e.errors = append(e.errors, &gqlErr)
+}
+
+// call runs a resolver, waiting for a free slot if the concurrency is limited
+//
+// Only the resolver itself holds a slot, so that a parent waiting on its
+// children never keeps them from running
+func (e *execution) call(path []interface{}, fn func() (interface{}, error)) (result interface{}, err error) {
+	if e.limit != nil {
+		select {
+		case e.limit <- struct{}{}:
+		case <-e.ctx.Done():
+			return nil, e.ctx.Err()
+		}
+		defer func() { <-e.limit }()
+	}
+	if err := e.ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while resolving %v: %v", path, r)
+		}
+	}()
+
+	return fn()
+}
+
+// parallel calls fn for each i below n, in goroutines unless serial is set
+func (e *execution) parallel(n int, serial bool, fn func(i int)) {
+	if serial || e.serial || n < 2 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// fieldGroups holds fields grouped by response key, in the order the keys were first seen
+type fieldGroups struct {
+	keys   []string
+	fields map[string][]*ast.Field
+}
+
+func (g *fieldGroups) add(key string, field *ast.Field) {
+	if g.fields == nil {
+		g.fields = make(map[string][]*ast.Field)
+	}
+	if _, ok := g.fields[key]; !ok {
+		g.keys = append(g.keys, key)
+	}
+	g.fields[key] = append(g.fields[key], field)
+}
+
+// executeSelectionSet resolves the fields selected on obj, which is a
+// resolver.ObjectContext or a resolver.Object, one after the other in
+// selection order if serial is set
+//
+// objType is the type of obj when executing with a schema, and nil otherwise.
+// When a non-null field resolves to null, the whole object is null and errored is set
+func (e *execution) executeSelectionSet(sel []ast.Selection, obj interface{}, objType *schema.Object, path []interface{}, serial bool) (out *Map, errored bool) {
+	var groups fieldGroups
+	e.collectFields(obj, objType, sel, make(map[string]bool), &groups)
+
+	values := make([]interface{}, len(groups.keys))
+	bubble := make([]bool, len(groups.keys))
+	e.parallel(len(groups.keys), serial, func(i int) {
+		key := groups.keys[i]
+		values[i], bubble[i] = e.executeField(obj, objType, groups.fields[key], appendPath(path, key))
+	})
+
+	out = &Map{}
+	for i, key := range groups.keys {
+		if bubble[i] {
+			return nil, true
+		}
+		out.Set(key, values[i])
+	}
+	return out, false
+}
+
+func (e *execution) collectFields(obj interface{}, objType *schema.Object, sel []ast.Selection, visited map[string]bool, groups *fieldGroups) {
+	for _, s := range sel {
+		switch {
+		case s.Field != nil:
+			if !e.shouldInclude(s.Field.Directives) {
+				continue
+			}
+			key := s.Field.Name
+			if s.Field.Alias != nil {
+				key = *s.Field.Alias
+			}
+			groups.add(key, s.Field)
+		case s.FragmentSpread != nil:
+			if !e.shouldInclude(s.FragmentSpread.Directives) || visited[s.FragmentSpread.Name] {
+				continue
+			}
+			visited[s.FragmentSpread.Name] = true
+			frag, ok := e.doc.Fragments[s.FragmentSpread.Name]
+			if !ok || !e.doesFragmentTypeApply(obj, objType, frag.Type) {
+				continue
+			}
+			e.collectFields(obj, objType, frag.SelectionSet, visited, groups)
+		case s.InlineFragment != nil:
+			if !e.shouldInclude(s.InlineFragment.Directives) {
+				continue
+			}
+			if s.InlineFragment.Type != nil && !e.doesFragmentTypeApply(obj, objType, *s.InlineFragment.Type) {
+				continue
+			}
+			e.collectFields(obj, objType, s.InlineFragment.SelectionSet, visited, groups)
+		}
+	}
+}
+
+func (e *execution) shouldInclude(directives []ast.Directive) bool {
+	for _, d := range directives {
+		cond, _ := d.Arguments.Get("if")
+		switch d.Name {
+		case "skip":
+			if valueFromAST(cond, e.vars) == true {
+				return false
+			}
+		case "include":
+			if valueFromAST(cond, e.vars) != true {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// executeField resolves and completes the value of one response key
+// bubble is set when the field is non-null but resolved to null
+func (e *execution) executeField(obj interface{}, objType *schema.Object, fields []*ast.Field, path []interface{}) (v interface{}, bubble bool) {
+	field := fields[0]
+
+	t, argDefs := e.fieldDef(objType, field.Name)
+	args, err := argumentValues(field.Arguments, argDefs, e.vars)
+	if err != nil {
+		e.addError(err, field, path)
+		return nil, isNonNull(t)
+	}
+
+	switch {
+	case field.Name == "__typename":
+		v, err = e.typeName(obj, objType)
+	case objType != nil && objType == e.schema.Query && (field.Name == "__schema" || field.Name == "__type"):
+		v = e.introspect(field.Name, args)
+	default:
+		v, err = e.call(path, func() (interface{}, error) {
+			if obj, ok := obj.(resolver.ObjectContext); ok {
+				return obj.ResolveContext(e.ctx, field.Name, args)
+			}
+			return obj.(resolver.Object).Resolve(field.Name, args)
+		})
+	}
+	if err != nil {
+		e.addError(err, field, path)
+		return nil, isNonNull(t)
+	}
+
+	v, errored := e.completeValue(t, fields, args, v, path)
+	return v, v == nil && errored && isNonNull(t)
+}
+
+// fieldDef returns the type and arguments of a field, which are nil when
+// executing without a schema
+func (e *execution) fieldDef(objType *schema.Object, name string) (schema.Type, schema.InputValueList) {
+	if objType == nil {
+		return nil, nil
+	}
+	def := e.schema.FieldDef(objType, name)
+	if def == nil {
+		return nil, nil
+	}
+	return def.Type, def.Args
+}
+
+// completeValue turns the value a resolver returned into its response value
+//
+// t is the type of the field when executing with a schema, and nil otherwise.
+// errored is set when the value is null because of an error that was already added
+func (e *execution) completeValue(t schema.Type, fields []*ast.Field, args resolver.Args, v interface{}, path []interface{}) (out interface{}, errored bool) {
+	if nn, ok := t.(*schema.NonNull); ok {
+		out, errored = e.completeValue(nn.OfType, fields, args, v, path)
+		if out == nil && !errored {
+			e.addError(fmt.Errorf("cannot return null for non-nullable field \"%s\"", fields[0].Name), fields[0], path)
+		}
+		return out, out == nil
+	}
+
+	sub := mergeSelectionSets(fields)
+
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+	case resolver.ObjectContext, resolver.Object:
+		if len(sub) == 0 {
+			e.addError(fmt.Errorf("field \"%s\" returned an object and must have a selection of subfields", fields[0].Name), fields[0], path)
+			return nil, true
+		}
+		objType, err := e.resolveObjectType(t, v)
+		if err != nil {
+			e.addError(err, fields[0], path)
+			return nil, true
+		}
+		m, errored := e.executeSelectionSet(sub, v, objType, path, false)
+		if m == nil {
+			return nil, errored
+		}
+		return m, false
+	case resolver.ArrayContext, resolver.Array:
+		var itemType schema.Type
+		if l, ok := t.(*schema.List); ok {
+			itemType = l.OfType
+		} else if t != nil {
+			e.addError(fmt.Errorf("field \"%s\" returned a list but is of type \"%s\"", fields[0].Name, t), fields[0], path)
+			return nil, true
+		}
+
+		list := make([]interface{}, v.(interface{ Len() int }).Len())
+		bubble := make([]bool, len(list))
+		e.parallel(len(list), len(sub) == 0, func(i int) {
+			itemPath := appendPath(path, i)
+			item, err := e.call(itemPath, func() (interface{}, error) {
+				if v, ok := v.(resolver.ArrayContext); ok {
+					return v.GetContext(e.ctx, i)
+				}
+				return v.(resolver.Array).Get(i)
+			})
+			if err != nil {
+				e.addError(err, fields[0], itemPath)
+				bubble[i] = isNonNull(itemType)
+				return
+			}
+			var errored bool
+			list[i], errored = e.completeValue(itemType, fields, args, item, itemPath)
+			bubble[i] = list[i] == nil && errored && isNonNull(itemType)
+		})
+		for _, b := range bubble {
+			if b {
+				return nil, true
+			}
+		}
+		return list, false
+	case resolver.QueryContext, resolver.Query:
+		res, err := e.call(path, func() (interface{}, error) {
+			if v, ok := v.(resolver.QueryContext); ok {
+				return v.ResolveContext(e.ctx, args)
+			}
+			return v.(resolver.Query).Resolve(args)
+		})
+		if err != nil {
+			e.addError(err, fields[0], path)
+			return nil, true
+		}
+		return e.completeValue(t, fields, args, res, path)
+	case resolver.Scalar:
+		// like a Query, a Scalar gets the arguments of the field
+		res, err := e.call(path, func() (interface{}, error) {
+			return v(args)
+		})
+		if err != nil {
+			e.addError(err, fields[0], path)
+			return nil, true
+		}
+		return e.completeValue(t, fields, args, res, path)
+	}
+
+	if wrapped, ok := wrapReflect(v, len(sub) > 0); ok {
+		return e.completeValue(t, fields, args, wrapped, path)
+	}
+	if len(sub) > 0 {
+		e.addError(fmt.Errorf("field \"%s\" returned a leaf value and must not have a selection of subfields", fields[0].Name), fields[0], path)
+		return nil, true
+	}
+	out, ok := coerceResult(v, t)
+	if !ok {
+		e.addError(fmt.Errorf("field \"%s\" returned %s, which is not a value of type \"%s\"", fields[0].Name, describe(v), t), fields[0], path)
+		return nil, true
+	}
+	return out, false
+}
+
+func isNonNull(t schema.Type) bool {
+	_, ok := t.(*schema.NonNull)
+	return ok
+}
+
+func mergeSelectionSets(fields []*ast.Field) (sel []ast.Selection) {
+	for _, f := range fields {
+		sel = append(sel, f.SelectionSet...)
+	}
+	return
+}
+
+// appendPath returns a copy of path with key appended, so that sibling paths never share storage
+func appendPath(path []interface{}, key interface{}) []interface{} {
+	out := make([]interface{}, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, key)
+}
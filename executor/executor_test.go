@@ -0,0 +1,491 @@
+package executor_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dianelooney/graphql/executor"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/parser"
+	"github.com/dianelooney/graphql/resolver"
+	"github.com/dianelooney/graphql/schema"
+)
+
+type obj struct {
+	typ    string
+	fields map[string]interface{}
+}
+
+func (o obj) TypeName() string {
+	return o.typ
+}
+func (o obj) Resolve(field string, args resolver.Args) (interface{}, error) {
+	v, ok := o.fields[field]
+	if !ok {
+		return nil, errors.New("missing field " + field)
+	}
+	if f, ok := v.(func(resolver.Args) (interface{}, error)); ok {
+		return f(args)
+	}
+	return v, nil
+}
+
+type Person struct {
+	Name    string
+	Friends []*Person
+}
+
+func root() obj {
+	return obj{"Query", map[string]interface{}{
+		"hello": "world",
+		"echo": func(args resolver.Args) (interface{}, error) {
+			return args["msg"], nil
+		},
+		"fail": func(args resolver.Args) (interface{}, error) {
+			return nil, errors.New("boom")
+		},
+		"forbidden": func(args resolver.Args) (interface{}, error) {
+			return nil, &gqlerror.Error{Message: "no", Extensions: map[string]interface{}{"code": "FORBIDDEN"}}
+		},
+		"pets": []interface{}{
+			obj{"Dog", map[string]interface{}{"name": "Rex", "barks": true}},
+			obj{"Cat", map[string]interface{}{"name": "Tom", "meows": true}},
+		},
+		"Me": &Person{Name: "Ann", Friends: []*Person{{Name: "Bob"}}},
+	}}
+}
+
+func expectResult(t *testing.T, src string, opName string, vars map[string]interface{}, expected string) {
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+
+	res := executor.Execute(context.Background(), doc, opName, vars, resolver.AdaptObject(root()))
+	out, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("Error marshalling result of %s: %v", src, err)
+	}
+	if string(out) != expected {
+		t.Errorf("Execute(%s) returned\n%s\nexpected\n%s", src, out, expected)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	expectResult(t, `{ hello greeting: hello }`, "", nil,
+		`{"data":{"hello":"world","greeting":"world"}}`)
+	expectResult(t, `{ echo(msg: "hi") }`, "", nil,
+		`{"data":{"echo":"hi"}}`)
+	expectResult(t, `{ hello fail }`, "", nil,
+		`{"data":{"hello":"world","fail":null},"errors":[{"message":"boom","locations":[{"line":1,"column":9}],"path":["fail"]}]}`)
+	expectResult(t, `{ forbidden }`, "", nil,
+		`{"data":{"forbidden":null},"errors":[{"message":"no","locations":[{"line":1,"column":3}],"path":["forbidden"],"extensions":{"code":"FORBIDDEN"}}]}`)
+	expectResult(t, `{ Me { Name Friends { Name } } }`, "", nil,
+		`{"data":{"Me":{"Name":"Ann","Friends":[{"Name":"Bob"}]}}}`)
+}
+
+func TestExecuteFragments(t *testing.T) {
+	expectResult(t, `
+	{ pets { name ...DogFields ... on Cat { meows } } }
+	fragment DogFields on Dog { barks }
+	`, "", nil, `{"data":{"pets":[{"name":"Rex","barks":true},{"name":"Tom","meows":true}]}}`)
+	expectResult(t, `
+	{ hello @skip(if: true) ... @include(if: false) { echo } }
+	`, "", nil, `{"data":{}}`)
+}
+
+func TestExecuteOperationName(t *testing.T) {
+	src := `query A { hello } query B { greeting: hello }`
+	expectResult(t, src, "B", nil, `{"data":{"greeting":"world"}}`)
+	expectResult(t, src, "", nil,
+		`{"errors":[{"message":"an operation name is required when the document contains multiple operations"}]}`)
+	expectResult(t, src, "C", nil, `{"errors":[{"message":"unknown operation named \"C\""}]}`)
+}
+
+func TestExecuteValidates(t *testing.T) {
+	p := parser.Parser{}
+	p.Init([]byte(`type Query { hello: String }`))
+	s, errs := schema.Build(p.Parse())
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	p.Init([]byte(`{ hello fail }`))
+	ex := executor.Executor{Schema: s}
+	res := ex.Execute(context.Background(), p.Parse(), "", nil, resolver.AdaptObject(root()))
+	out, _ := json.Marshal(res)
+	expected := `{"errors":[{"message":"cannot query field \"fail\" on type \"Query\"","locations":[{"line":1,"column":9}]}]}`
+	if string(out) != expected {
+		t.Errorf("Execute returned\n%s\nexpected\n%s", out, expected)
+	}
+}
+
+// tracker is an Object whose fields sleep, recording the order they ran
+// in and the most fields that were running at the same time
+type tracker struct {
+	mu      sync.Mutex
+	running int
+	max     int
+	order   []string
+}
+
+func (tr *tracker) Resolve(field string, args resolver.Args) (interface{}, error) {
+	tr.mu.Lock()
+	tr.running++
+	if tr.running > tr.max {
+		tr.max = tr.running
+	}
+	tr.order = append(tr.order, field)
+	tr.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	tr.mu.Lock()
+	tr.running--
+	tr.mu.Unlock()
+	return field, nil
+}
+
+func TestExecuteConcurrency(t *testing.T) {
+	tests := []struct {
+		src            string
+		maxConcurrency int
+		expectedMax    int
+	}{
+		{`{ a b c d }`, 0, 4},
+		{`{ a b c d }`, 2, 2},
+		{`{ a b c d }`, 1, 1},
+		{`mutation { a b c d }`, 0, 1},
+	}
+	for _, test := range tests {
+		p := parser.Parser{}
+		p.Init([]byte(test.src))
+		doc := p.Parse()
+
+		tr := &tracker{}
+		ex := executor.Executor{MaxConcurrency: test.maxConcurrency}
+		res := ex.Execute(context.Background(), doc, "", nil, resolver.AdaptObject(tr))
+		out, _ := json.Marshal(res)
+		if string(out) != `{"data":{"a":"a","b":"b","c":"c","d":"d"}}` {
+			t.Errorf("Execute(%s) returned %s", test.src, out)
+		}
+		if tr.max != test.expectedMax {
+			t.Errorf("Execute(%s) with MaxConcurrency %d ran %d resolvers at once, expected %d",
+				test.src, test.maxConcurrency, tr.max, test.expectedMax)
+		}
+		if test.expectedMax == 1 && strings.Join(tr.order, "") != "abcd" {
+			t.Errorf("Execute(%s) resolved fields in the order %v", test.src, tr.order)
+		}
+	}
+}
+
+type ctxKey struct{}
+
+type User struct{}
+
+func (User) Name(ctx context.Context) string {
+	return ctx.Value(ctxKey{}).(string)
+}
+
+func TestExecuteContext(t *testing.T) {
+	p := parser.Parser{}
+	p.Init([]byte(`{ Name }`))
+	doc := p.Parse()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "from context")
+	res := executor.Execute(ctx, doc, "", nil, resolver.Reflect{Target: User{}})
+	out, _ := json.Marshal(res)
+	if string(out) != `{"data":{"Name":"from context"}}` {
+		t.Errorf("Execute returned %s", out)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	res = executor.Execute(ctx, doc, "", nil, resolver.Reflect{Target: User{}})
+	out, _ = json.Marshal(res)
+	if string(out) != `{"data":{"Name":null},"errors":[{"message":"context canceled","locations":[{"line":1,"column":3}],"path":["Name"]}]}` {
+		t.Errorf("Execute with a cancelled context returned %s", out)
+	}
+}
+
+const petSchema = `
+type Query {
+	hello: String!
+	fail: String!
+	pets: [Pet]
+}
+"A pet"
+interface Pet { name: String }
+type Dog implements Pet { name: String barks: Boolean }
+type Cat implements Pet {
+	name: String
+	meows: Boolean @deprecated(reason: "cats purr")
+}
+enum Size { SMALL LARGE @deprecated }
+`
+
+func expectSchemaResult(t *testing.T, src string, expected string) {
+	p := parser.Parser{}
+	p.Init([]byte(petSchema))
+	s, errs := schema.Build(p.Parse())
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+	ex := executor.Executor{Schema: s}
+	res := ex.Execute(context.Background(), doc, "", nil, resolver.AdaptObject(root()))
+	out, _ := json.Marshal(res)
+	if string(out) != expected {
+		t.Errorf("Execute(%s) returned\n%s\nexpected\n%s", src, out, expected)
+	}
+}
+
+func TestExecuteIntrospection(t *testing.T) {
+	expectSchemaResult(t, `{ __typename pets { __typename name } }`,
+		`{"data":{"__typename":"Query","pets":[{"__typename":"Dog","name":"Rex"},{"__typename":"Cat","name":"Tom"}]}}`)
+	expectSchemaResult(t, `{ __schema { queryType { name } mutationType { name } } }`,
+		`{"data":{"__schema":{"queryType":{"name":"Query"},"mutationType":null}}}`)
+	expectSchemaResult(t, `{ __type(name: "Pet") { kind description possibleTypes { name } } }`,
+		`{"data":{"__type":{"kind":"INTERFACE","description":"A pet","possibleTypes":[{"name":"Cat"},{"name":"Dog"}]}}}`)
+	expectSchemaResult(t, `{ __type(name: "Cat") { fields { name } all: fields(includeDeprecated: true) { name isDeprecated deprecationReason } } }`,
+		`{"data":{"__type":{"fields":[{"name":"name"}],"all":[{"name":"name","isDeprecated":false,"deprecationReason":null},{"name":"meows","isDeprecated":true,"deprecationReason":"cats purr"}]}}}`)
+	expectSchemaResult(t, `{ __type(name: "Size") { enumValues { name } } }`,
+		`{"data":{"__type":{"enumValues":[{"name":"SMALL"}]}}}`)
+	expectSchemaResult(t, `{ __type(name: "Query") { fields { name type { kind ofType { name } } } } }`,
+		`{"data":{"__type":{"fields":[{"name":"hello","type":{"kind":"NON_NULL","ofType":{"name":"String"}}},{"name":"fail","type":{"kind":"NON_NULL","ofType":{"name":"String"}}},{"name":"pets","type":{"kind":"LIST","ofType":{"name":"Pet"}}}]}}}`)
+	expectSchemaResult(t, `{ __schema { directives { name isRepeatable } } }`,
+		`{"data":{"__schema":{"directives":[{"name":"deprecated","isRepeatable":false},{"name":"include","isRepeatable":false},{"name":"skip","isRepeatable":false},{"name":"specifiedBy","isRepeatable":false}]}}}`)
+	expectSchemaResult(t, `{ __type(name: "Missing") { name } }`,
+		`{"data":{"__type":null}}`)
+}
+
+func TestExecuteNonNull(t *testing.T) {
+	expectSchemaResult(t, `{ hello fail }`,
+		`{"data":null,"errors":[{"message":"boom","locations":[{"line":1,"column":9}],"path":["fail"]}]}`)
+}
+
+func TestSubscribe(t *testing.T) {
+	p := parser.Parser{}
+	p.Init([]byte(`subscription { messages(room: "a") { text } }`))
+	doc := p.Parse()
+
+	events := make(chan interface{})
+	var room interface{}
+	root := obj{"Subscription", map[string]interface{}{
+		"messages": func(args resolver.Args) (interface{}, error) {
+			room = args["room"]
+			return (<-chan interface{})(events), nil
+		},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, errs := executor.Subscribe(ctx, doc, "", nil, resolver.AdaptObject(root))
+	if errs != nil {
+		t.Fatalf("Subscribe returned errors %v", errs)
+	}
+	if room != "a" {
+		t.Errorf("subscription field was resolved with room %v", room)
+	}
+
+	go func() {
+		events <- obj{"Message", map[string]interface{}{"text": "hi"}}
+		events <- errors.New("dropped")
+	}()
+	for _, expected := range []string{
+		`{"data":{"messages":{"text":"hi"}}}`,
+		`{"data":{"messages":null},"errors":[{"message":"dropped","locations":[{"line":1,"column":16}],"path":["messages"]}]}`,
+	} {
+		out, _ := json.Marshal(<-stream)
+		if string(out) != expected {
+			t.Errorf("event returned\n%s\nexpected\n%s", out, expected)
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-stream:
+		if ok {
+			t.Errorf("stream returned a result after the context was cancelled")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("stream was not closed after the context was cancelled")
+	}
+
+	ctx = context.Background()
+	p.Init([]byte(`{ hello }`))
+	if _, errs := executor.Subscribe(ctx, p.Parse(), "", nil, resolver.AdaptObject(root)); len(errs) != 1 || errs[0].Message != "query operations must be run with Execute" {
+		t.Errorf("Subscribe to a query returned %v", errs)
+	}
+	p.Init([]byte(`subscription { messages }`))
+	root.fields["messages"] = "not a stream"
+	if _, errs := executor.Subscribe(ctx, p.Parse(), "", nil, resolver.AdaptObject(root)); len(errs) != 1 || errs[0].Message != `subscription field "messages" must return a channel, got string` {
+		t.Errorf("Subscribe to a field that is not a stream returned %v", errs)
+	}
+}
+
+func TestCoerceVariableValues(t *testing.T) {
+	p := parser.Parser{}
+	p.Init([]byte(`
+	type Query { f(a: Int): Int }
+	enum Color { RED GREEN }
+	input Filter { color: Color = RED limit: Int! tags: [String] }
+	`))
+	s, errs := schema.Build(p.Parse())
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	p.Init([]byte(`query ($id: ID!, $n: Float, $ints: [Int], $f: Filter, $d: Int = 3, $opt: String) { f }`))
+	op := p.Parse().Operation
+	for _, err := range p.Errors() {
+		t.Fatal(err)
+	}
+
+	vars, coerceErrs := executor.CoerceVariableValues(s, op, map[string]interface{}{
+		"id":   float64(7),
+		"n":    float64(2),
+		"ints": float64(1),
+		"f":    map[string]interface{}{"limit": float64(10), "tags": "a"},
+	})
+	if len(coerceErrs) > 0 {
+		t.Fatalf("CoerceVariableValues returned errors %v", coerceErrs)
+	}
+	out, _ := json.Marshal(vars)
+	expected := `{"d":3,"f":{"color":"RED","limit":10,"tags":["a"]},"id":"7","ints":[1],"n":2}`
+	if string(out) != expected {
+		t.Errorf("CoerceVariableValues returned\n%s\nexpected\n%s", out, expected)
+	}
+	if _, ok := vars["ints"].([]interface{})[0].(int); !ok {
+		t.Errorf("Expected Int values to be coerced to int, got %T", vars["ints"].([]interface{})[0])
+	}
+
+	tests := []struct {
+		inputs   map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{}, `variable "$id" of required type "ID!" was not provided`},
+		{map[string]interface{}{"id": nil}, `variable "$id" got an invalid value: $id must not be null, it is of type "ID!"`},
+		{map[string]interface{}{"id": "1", "ints": []interface{}{1.5}}, `variable "$ints" got an invalid value: $ints.0 must be of type "Int", found 1.5`},
+		{map[string]interface{}{"id": "1", "f": map[string]interface{}{"limit": 1, "color": "BLUE"}}, `variable "$f" got an invalid value: $f.color must be a value of enum "Color", found "BLUE"`},
+		{map[string]interface{}{"id": "1", "f": map[string]interface{}{}}, `variable "$f" got an invalid value: $f.limit of required type "Int!" was not provided`},
+		{map[string]interface{}{"id": "1", "f": map[string]interface{}{"limit": 1, "x": 1}}, `variable "$f" got an invalid value: $f has a field "x" that is not defined by type "Filter"`},
+	}
+	for _, test := range tests {
+		_, errs := executor.CoerceVariableValues(s, op, test.inputs)
+		if len(errs) != 1 || errs[0].Message != test.expected {
+			t.Errorf("CoerceVariableValues(%v) returned %v, expected %q", test.inputs, errs, test.expected)
+		}
+	}
+}
+
+func TestExecuteVariables(t *testing.T) {
+	p := parser.Parser{}
+	p.Init([]byte(`type Query { echo(msg: String, n: Float = 1): String }`))
+	s, errs := schema.Build(p.Parse())
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	var got resolver.Args
+	root := obj{"Query", map[string]interface{}{
+		"echo": func(args resolver.Args) (interface{}, error) {
+			got = args
+			return args["msg"], nil
+		},
+	}}
+	ex := executor.Executor{Schema: s}
+
+	p.Init([]byte(`query ($m: String!) { echo(msg: $m) }`))
+	doc := p.Parse()
+	res := ex.Execute(context.Background(), doc, "", map[string]interface{}{"m": "hi"}, resolver.AdaptObject(root))
+	out, _ := json.Marshal(res)
+	if string(out) != `{"data":{"echo":"hi"}}` {
+		t.Errorf("Execute returned %s", out)
+	}
+	if n, ok := got["n"].(float64); !ok || n != 1 {
+		t.Errorf("Expected the default of n to be coerced to a float, got %#v", got["n"])
+	}
+
+	res = ex.Execute(context.Background(), doc, "", map[string]interface{}{"m": 1}, resolver.AdaptObject(root))
+	out, _ = json.Marshal(res)
+	if string(out) != `{"errors":[{"message":"variable \"$m\" got an invalid value: $m must be of type \"String\", found 1"}]}` {
+		t.Errorf("Execute with an invalid variable returned %s", out)
+	}
+}
+
+type Label string
+
+func TestExecuteCoerceResult(t *testing.T) {
+	p := parser.Parser{}
+	p.Init([]byte(`
+	type Query {
+		i: Int f: Float s: String b: Boolean id: ID e: Color date: Date
+		nonNull: Int! child: Child
+	}
+	type Child { i: Int! }
+	enum Color { RED GREEN }
+	scalar Date
+	`))
+	s, errs := schema.Build(p.Parse())
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+	ex := executor.Executor{Schema: s}
+
+	tests := []struct {
+		field    string
+		value    interface{}
+		expected string
+	}{
+		{"i", int64(7), `{"data":{"i":7}}`},
+		{"i", 2.0, `{"data":{"i":2}}`},
+		{"i", "notanint", `{"data":{"i":null},"errors":[{"message":"field \"i\" returned \"notanint\", which is not a value of type \"Int\"","locations":[{"line":1,"column":3}],"path":["i"]}]}`},
+		{"i", 1 << 40, `{"data":{"i":null},"errors":[{"message":"field \"i\" returned 1099511627776, which is not a value of type \"Int\"","locations":[{"line":1,"column":3}],"path":["i"]}]}`},
+		{"i", 1.5, `{"data":{"i":null},"errors":[{"message":"field \"i\" returned 1.5, which is not a value of type \"Int\"","locations":[{"line":1,"column":3}],"path":["i"]}]}`},
+		{"f", 3, `{"data":{"f":3}}`},
+		{"f", "x", `{"data":{"f":null},"errors":[{"message":"field \"f\" returned \"x\", which is not a value of type \"Float\"","locations":[{"line":1,"column":3}],"path":["f"]}]}`},
+		{"s", Label("hi"), `{"data":{"s":"hi"}}`},
+		{"s", 42, `{"data":{"s":null},"errors":[{"message":"field \"s\" returned 42, which is not a value of type \"String\"","locations":[{"line":1,"column":3}],"path":["s"]}]}`},
+		{"b", true, `{"data":{"b":true}}`},
+		{"b", "x", `{"data":{"b":null},"errors":[{"message":"field \"b\" returned \"x\", which is not a value of type \"Boolean\"","locations":[{"line":1,"column":3}],"path":["b"]}]}`},
+		{"id", 12, `{"data":{"id":"12"}}`},
+		{"id", "a1", `{"data":{"id":"a1"}}`},
+		{"id", true, `{"data":{"id":null},"errors":[{"message":"field \"id\" returned true, which is not a value of type \"ID\"","locations":[{"line":1,"column":3}],"path":["id"]}]}`},
+		{"e", "RED", `{"data":{"e":"RED"}}`},
+		{"e", "NOPE", `{"data":{"e":null},"errors":[{"message":"field \"e\" returned \"NOPE\", which is not a value of type \"Color\"","locations":[{"line":1,"column":3}],"path":["e"]}]}`},
+		{"date", map[string]int{"y": 2020}, `{"data":{"date":{"y":2020}}}`},
+		{"nonNull", "x", `{"data":null,"errors":[{"message":"field \"nonNull\" returned \"x\", which is not a value of type \"Int\"","locations":[{"line":1,"column":3}],"path":["nonNull"]}]}`},
+	}
+	for _, test := range tests {
+		src := "{ " + test.field + " }"
+		p.Init([]byte(src))
+		root := obj{"Query", map[string]interface{}{test.field: test.value}}
+		res := ex.Execute(context.Background(), p.Parse(), "", nil, resolver.AdaptObject(root))
+		out, _ := json.Marshal(res)
+		if string(out) != test.expected {
+			t.Errorf("Execute(%s) returning %#v returned\n%s\nexpected\n%s", src, test.value, out, test.expected)
+		}
+	}
+
+	p.Init([]byte(`{ child { i } }`))
+	root := obj{"Query", map[string]interface{}{"child": obj{"Child", map[string]interface{}{"i": "x"}}}}
+	res := ex.Execute(context.Background(), p.Parse(), "", nil, resolver.AdaptObject(root))
+	out, _ := json.Marshal(res)
+	expected := `{"data":{"child":null},"errors":[{"message":"field \"i\" returned \"x\", which is not a value of type \"Int\"","locations":[{"line":1,"column":11}],"path":["child","i"]}]}`
+	if string(out) != expected {
+		t.Errorf("Execute with an invalid non-null result returned\n%s\nexpected\n%s", out, expected)
+	}
+}
@@ -0,0 +1,329 @@
+package executor
+
+import (
+	"sort"
+
+	"github.com/dianelooney/graphql/printer"
+	"github.com/dianelooney/graphql/resolver"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// introspect resolves the __schema and __type meta fields of the query root type
+func (e *execution) introspect(field string, args resolver.Args) interface{} {
+	if field == "__schema" {
+		return schemaObject{e.schema}
+	}
+
+	name, _ := args["name"].(string)
+	t := e.schema.Type(name)
+	if t == nil {
+		return nil
+	}
+	return typeObject{e.schema, t}
+}
+
+// description returns nil for an empty description, so that it is sent as null
+func description(desc string) interface{} {
+	if desc == "" {
+		return nil
+	}
+	return desc
+}
+
+func deprecation(reason string, deprecated bool) (isDeprecated bool, deprecationReason interface{}) {
+	if !deprecated {
+		return false, nil
+	}
+	return true, reason
+}
+
+// includeDeprecated reads the includeDeprecated argument shared by several introspection fields
+func includeDeprecated(args resolver.Args) bool {
+	b, _ := args["includeDeprecated"].(bool)
+	return b
+}
+
+type schemaObject struct {
+	s *schema.Schema
+}
+
+func (schemaObject) TypeName() string { return "__Schema" }
+
+func (o schemaObject) Resolve(field string, args resolver.Args) (interface{}, error) {
+	switch field {
+	case "types":
+		names := make([]string, 0, len(o.s.Types))
+		for name := range o.s.Types {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		types := make([]interface{}, len(names))
+		for i, name := range names {
+			types[i] = typeObject{o.s, o.s.Types[name]}
+		}
+		return types, nil
+	case "queryType":
+		return o.rootType(o.s.Query), nil
+	case "mutationType":
+		return o.rootType(o.s.Mutation), nil
+	case "subscriptionType":
+		return o.rootType(o.s.Subscription), nil
+	case "directives":
+		names := make([]string, 0, len(o.s.Directives))
+		for name := range o.s.Directives {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		directives := make([]interface{}, len(names))
+		for i, name := range names {
+			directives[i] = directiveObject{o.s, o.s.Directives[name]}
+		}
+		return directives, nil
+	}
+	return nil, nil
+}
+
+func (o schemaObject) rootType(t *schema.Object) interface{} {
+	if t == nil {
+		return nil
+	}
+	return typeObject{o.s, t}
+}
+
+type typeObject struct {
+	s *schema.Schema
+	t schema.Type
+}
+
+func (typeObject) TypeName() string { return "__Type" }
+
+func (o typeObject) Resolve(field string, args resolver.Args) (interface{}, error) {
+	switch field {
+	case "kind":
+		return typeKind(o.t), nil
+	case "name":
+		switch o.t.(type) {
+		case *schema.List, *schema.NonNull:
+			return nil, nil
+		}
+		return o.t.String(), nil
+	case "description":
+		switch t := o.t.(type) {
+		case *schema.Scalar:
+			return description(t.Description), nil
+		case *schema.Object:
+			return description(t.Description), nil
+		case *schema.Interface:
+			return description(t.Description), nil
+		case *schema.Union:
+			return description(t.Description), nil
+		case *schema.Enum:
+			return description(t.Description), nil
+		case *schema.InputObject:
+			return description(t.Description), nil
+		}
+	case "specifiedByURL":
+		if t, ok := o.t.(*schema.Scalar); ok {
+			if url, ok := t.SpecifiedByURL(); ok {
+				return url, nil
+			}
+		}
+	case "fields":
+		var fields schema.FieldList
+		switch t := o.t.(type) {
+		case *schema.Object:
+			fields = t.Fields
+		case *schema.Interface:
+			fields = t.Fields
+		default:
+			return nil, nil
+		}
+		out := make([]interface{}, 0, len(fields))
+		for _, f := range fields {
+			if _, deprecated := f.Deprecation(); deprecated && !includeDeprecated(args) {
+				continue
+			}
+			out = append(out, fieldObject{o.s, f})
+		}
+		return out, nil
+	case "interfaces":
+		var interfaces []*schema.Interface
+		switch t := o.t.(type) {
+		case *schema.Object:
+			interfaces = t.Interfaces
+		case *schema.Interface:
+			interfaces = t.Interfaces
+		default:
+			return nil, nil
+		}
+		out := make([]interface{}, len(interfaces))
+		for i, intf := range interfaces {
+			out[i] = typeObject{o.s, intf}
+		}
+		return out, nil
+	case "possibleTypes":
+		if schema.IsAbstractType(o.t) {
+			possible := o.s.PossibleTypes(o.t)
+			out := make([]interface{}, len(possible))
+			for i, p := range possible {
+				out[i] = typeObject{o.s, p}
+			}
+			return out, nil
+		}
+	case "enumValues":
+		if t, ok := o.t.(*schema.Enum); ok {
+			out := make([]interface{}, 0, len(t.Values))
+			for _, v := range t.Values {
+				if _, deprecated := v.Deprecation(); deprecated && !includeDeprecated(args) {
+					continue
+				}
+				out = append(out, enumValueObject{v})
+			}
+			return out, nil
+		}
+	case "inputFields":
+		if t, ok := o.t.(*schema.InputObject); ok {
+			return inputValues(o.s, t.Fields, args), nil
+		}
+	case "ofType":
+		switch t := o.t.(type) {
+		case *schema.List:
+			return typeObject{o.s, t.OfType}, nil
+		case *schema.NonNull:
+			return typeObject{o.s, t.OfType}, nil
+		}
+	}
+	return nil, nil
+}
+
+func typeKind(t schema.Type) string {
+	switch t.(type) {
+	case *schema.Scalar:
+		return "SCALAR"
+	case *schema.Object:
+		return "OBJECT"
+	case *schema.Interface:
+		return "INTERFACE"
+	case *schema.Union:
+		return "UNION"
+	case *schema.Enum:
+		return "ENUM"
+	case *schema.InputObject:
+		return "INPUT_OBJECT"
+	case *schema.List:
+		return "LIST"
+	case *schema.NonNull:
+		return "NON_NULL"
+	}
+	return ""
+}
+
+func inputValues(s *schema.Schema, values schema.InputValueList, args resolver.Args) []interface{} {
+	out := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if _, deprecated := v.Deprecation(); deprecated && !includeDeprecated(args) {
+			continue
+		}
+		out = append(out, inputValueObject{s, v})
+	}
+	return out
+}
+
+type fieldObject struct {
+	s *schema.Schema
+	f *schema.Field
+}
+
+func (fieldObject) TypeName() string { return "__Field" }
+
+func (o fieldObject) Resolve(field string, args resolver.Args) (interface{}, error) {
+	isDeprecated, reason := deprecation(o.f.Deprecation())
+	switch field {
+	case "name":
+		return o.f.Name, nil
+	case "description":
+		return description(o.f.Description), nil
+	case "args":
+		return inputValues(o.s, o.f.Args, args), nil
+	case "type":
+		return typeObject{o.s, o.f.Type}, nil
+	case "isDeprecated":
+		return isDeprecated, nil
+	case "deprecationReason":
+		return reason, nil
+	}
+	return nil, nil
+}
+
+type inputValueObject struct {
+	s *schema.Schema
+	v *schema.InputValue
+}
+
+func (inputValueObject) TypeName() string { return "__InputValue" }
+
+func (o inputValueObject) Resolve(field string, args resolver.Args) (interface{}, error) {
+	isDeprecated, reason := deprecation(o.v.Deprecation())
+	switch field {
+	case "name":
+		return o.v.Name, nil
+	case "description":
+		return description(o.v.Description), nil
+	case "type":
+		return typeObject{o.s, o.v.Type}, nil
+	case "defaultValue":
+		if o.v.DefaultValue == nil {
+			return nil, nil
+		}
+		return printer.Sprint(*o.v.DefaultValue), nil
+	case "isDeprecated":
+		return isDeprecated, nil
+	case "deprecationReason":
+		return reason, nil
+	}
+	return nil, nil
+}
+
+type enumValueObject struct {
+	v *schema.EnumValue
+}
+
+func (enumValueObject) TypeName() string { return "__EnumValue" }
+
+func (o enumValueObject) Resolve(field string, args resolver.Args) (interface{}, error) {
+	isDeprecated, reason := deprecation(o.v.Deprecation())
+	switch field {
+	case "name":
+		return o.v.Name, nil
+	case "description":
+		return description(o.v.Description), nil
+	case "isDeprecated":
+		return isDeprecated, nil
+	case "deprecationReason":
+		return reason, nil
+	}
+	return nil, nil
+}
+
+type directiveObject struct {
+	s *schema.Schema
+	d *schema.Directive
+}
+
+func (directiveObject) TypeName() string { return "__Directive" }
+
+func (o directiveObject) Resolve(field string, args resolver.Args) (interface{}, error) {
+	switch field {
+	case "name":
+		return o.d.Name, nil
+	case "description":
+		return description(o.d.Description), nil
+	case "locations":
+		return o.d.Locations, nil
+	case "args":
+		return inputValues(o.s, o.d.Args, args), nil
+	case "isRepeatable":
+		return o.d.Repeatable, nil
+	}
+	return nil, nil
+}
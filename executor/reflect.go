@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/dianelooney/graphql/resolver"
+)
+
+// wrapReflect adapts plain Go values to the resolver interfaces
+//
+// Slices and arrays become an Array. Structs and maps with string keys
+// only become an Object when the field has a selection of subfields,
+// otherwise they are returned as a leaf value
+func wrapReflect(v interface{}, hasSelection bool) (wrapped interface{}, ok bool) {
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return nil, true
+		}
+		if val.Elem().Kind() == reflect.Struct {
+			if !hasSelection {
+				return nil, false
+			}
+			return resolver.Reflect{Target: v}, true
+		}
+		return val.Elem().Interface(), true
+	case reflect.Struct:
+		if !hasSelection {
+			return nil, false
+		}
+		return resolver.Reflect{Target: v}, true
+	case reflect.Map:
+		if !hasSelection || val.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		return reflectMap{val}, true
+	case reflect.Slice:
+		if val.IsNil() {
+			return nil, true
+		}
+		return reflectArray{val}, true
+	case reflect.Array:
+		return reflectArray{val}, true
+	}
+
+	return nil, false
+}
+
+type reflectArray struct {
+	val reflect.Value
+}
+
+func (a reflectArray) Len() int {
+	return a.val.Len()
+}
+func (a reflectArray) Get(i int) (result interface{}, err error) {
+	return a.val.Index(i).Interface(), nil
+}
+
+type reflectMap struct {
+	val reflect.Value
+}
+
+func (m reflectMap) Resolve(field string, args resolver.Args) (result interface{}, err error) {
+	v := m.val.MapIndex(reflect.ValueOf(field).Convert(m.val.Type().Key()))
+	if !v.IsValid() {
+		return nil, errors.New("missing field")
+	}
+	return v.Interface(), nil
+}
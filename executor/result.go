@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/dianelooney/graphql/gqlerror"
+)
+
+// Result is the response to an executed operation
+//
+// Data is nil when the operation failed before execution started,
+// in which case the "data" entry is left out of the JSON response
+type Result struct {
+	Data   *Map
+	Errors []*gqlerror.Error
+
+	executed bool
+}
+
+// Executed reports whether execution started, in which case the
+// response contains data, even if it is null
+func (r Result) Executed() bool {
+	return r.executed
+}
+
+// MarshalJSON encodes the result in the shape described by the spec's Response section
+func (r Result) MarshalJSON() ([]byte, error) {
+	out := Map{}
+	if r.executed {
+		if r.Data == nil {
+			out.Set("data", nil)
+		} else {
+			out.Set("data", r.Data)
+		}
+	}
+	if len(r.Errors) > 0 {
+		out.Set("errors", r.Errors)
+	}
+	return out.MarshalJSON()
+}
+
+// Map is a JSON object that remembers the order its keys were set in
+type Map struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// Set sets the value for k, appending k to the key order if it is new
+func (m *Map) Set(k string, v interface{}) {
+	if m.values == nil {
+		m.values = make(map[string]interface{})
+	}
+	if _, ok := m.values[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.values[k] = v
+}
+
+// Get returns the value for k
+func (m *Map) Get(k string) (v interface{}, ok bool) {
+	v, ok = m.values[k]
+	return
+}
+
+// Keys returns the keys of the map in the order they were set
+func (m *Map) Keys() []string {
+	return m.keys
+}
+
+// Len returns the number of keys in the map
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+// MarshalJSON encodes the map as a JSON object, preserving key order
+func (m *Map) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		val, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/resolver"
+	"github.com/dianelooney/graphql/validation"
+)
+
+// Subscribe runs a subscription operation from doc against root, without a schema
+//
+// See (*Executor).Subscribe
+func Subscribe(ctx context.Context, doc ast.Document, operationName string, variables map[string]interface{}, root resolver.ObjectContext) (<-chan Result, []*gqlerror.Error) {
+	return (&Executor{}).Subscribe(ctx, doc, operationName, variables, root)
+}
+
+// Subscribe runs a subscription operation from doc against root
+//
+// The single top level field of the subscription is resolved once, and must
+// return a receive-capable channel, the source stream. Every value received
+// from it is taken as the value of that field and completed with the
+// field's selection set, producing one Result on the returned channel. A
+// value that is an error produces a Result carrying that error instead.
+//
+// The returned channel is closed once the source stream is closed or ctx is
+// done. If the subscription cannot be started, it is nil and the errors
+// explain why
+func (ex *Executor) Subscribe(ctx context.Context, doc ast.Document, operationName string, variables map[string]interface{}, root resolver.ObjectContext) (<-chan Result, []*gqlerror.Error) {
+	var errs []*gqlerror.Error
+	if ex.Schema != nil {
+		for _, err := range validation.Validate(ex.Schema, doc) {
+			errs = append(errs, gqlerror.Wrap(err))
+		}
+		if len(errs) > 0 {
+			return nil, errs
+		}
+	}
+
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return nil, []*gqlerror.Error{gqlerror.Wrap(err)}
+	}
+	if op.OpType != "subscription" {
+		return nil, []*gqlerror.Error{gqlerror.Errorf("%s operations must be run with Execute", op.OpType)}
+	}
+
+	vars, errs := ex.variableValues(op, variables)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	e := ex.newExecution(ctx, doc, vars)
+	rootType := e.rootType(op)
+	var groups fieldGroups
+	e.collectFields(root, rootType, op.SelectionSet, make(map[string]bool), &groups)
+	if len(groups.keys) != 1 {
+		return nil, []*gqlerror.Error{gqlerror.Errorf("subscription operations must select exactly one top level field")}
+	}
+	key := groups.keys[0]
+	fields := groups.fields[key]
+	field := fields[0]
+	path := []interface{}{key}
+
+	_, argDefs := e.fieldDef(rootType, field.Name)
+	args, err := argumentValues(field.Arguments, argDefs, e.vars)
+	var source interface{}
+	if err == nil {
+		source, err = e.call(path, func() (interface{}, error) {
+			return root.ResolveContext(ctx, field.Name, args)
+		})
+	}
+	if err == nil {
+		err = checkSourceStream(field.Name, source)
+	}
+	if err != nil {
+		e.addError(err, field, path)
+		return nil, e.errors
+	}
+
+	out := make(chan Result)
+	go func() {
+		defer close(out)
+
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(source)},
+		}
+		for {
+			chosen, event, ok := reflect.Select(cases)
+			if chosen == 0 || !ok {
+				return
+			}
+
+			res := ex.executeEvent(ctx, doc, op, vars, key, fields, args, event.Interface())
+			select {
+			case out <- res:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// executeEvent completes one value from the source stream of a subscription
+func (ex *Executor) executeEvent(ctx context.Context, doc ast.Document, op *ast.Operation, vars map[string]interface{}, key string, fields []*ast.Field, args resolver.Args, event interface{}) (res Result) {
+	e := ex.newExecution(ctx, doc, vars)
+	path := []interface{}{key}
+	res.executed = true
+
+	var v interface{}
+	var bubble bool
+	t, _ := e.fieldDef(e.rootType(op), fields[0].Name)
+	if err, ok := event.(error); ok {
+		e.addError(err, fields[0], path)
+		bubble = isNonNull(t)
+	} else {
+		var errored bool
+		v, errored = e.completeValue(t, fields, args, event, path)
+		bubble = v == nil && errored && isNonNull(t)
+	}
+	if !bubble {
+		res.Data = &Map{}
+		res.Data.Set(key, v)
+	}
+	res.Errors = e.errors
+	return
+}
+
+// checkSourceStream reports an error unless v is a channel that can be received from
+func checkSourceStream(name string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Chan || rv.Type().ChanDir()&reflect.RecvDir == 0 {
+		return fmt.Errorf("subscription field \"%s\" must return a channel, got %T", name, v)
+	}
+	return nil
+}
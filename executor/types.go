@@ -0,0 +1,91 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/dianelooney/graphql/resolver"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// runtimeTypeName returns the name of the GraphQL type of obj, if it is known
+//
+// Objects implementing resolver.Typed name their own type, and a
+// resolver.Reflect is named after the Go type of its Target
+func runtimeTypeName(obj interface{}) string {
+	switch obj := obj.(type) {
+	case resolver.Typed:
+		return obj.TypeName()
+	case resolver.Reflect:
+		if obj.Target == nil {
+			return ""
+		}
+		t := reflect.TypeOf(obj.Target)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return t.Name()
+	}
+	return ""
+}
+
+// resolveObjectType finds the object type of obj, which was returned for a field of type t
+//
+// It returns nil when executing without a schema
+func (e *execution) resolveObjectType(t schema.Type, obj interface{}) (*schema.Object, error) {
+	if e.schema == nil || t == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *schema.Object:
+		return t, nil
+	case *schema.Interface, *schema.Union:
+		possible := e.schema.PossibleTypes(t)
+		name := runtimeTypeName(obj)
+		if name == "" {
+			if len(possible) == 1 {
+				return possible[0], nil
+			}
+			return nil, fmt.Errorf("could not determine the object type of abstract type \"%s\", "+
+				"the value should implement resolver.Typed", t)
+		}
+		for _, o := range possible {
+			if o.Name == name {
+				return o, nil
+			}
+		}
+		return nil, fmt.Errorf("runtime object type \"%s\" is not a possible type for \"%s\"", name, t)
+	}
+	return nil, fmt.Errorf("returned an object but is of type \"%s\"", t)
+}
+
+// typeName resolves the __typename meta field
+func (e *execution) typeName(obj interface{}, objType *schema.Object) (string, error) {
+	if objType != nil {
+		return objType.Name, nil
+	}
+	if name := runtimeTypeName(obj); name != "" {
+		return name, nil
+	}
+	return "", errors.New("could not determine __typename, the value should implement resolver.Typed")
+}
+
+// doesFragmentTypeApply reports whether a fragment on typ should be applied to obj
+//
+// Without a schema, objects that do not implement resolver.Typed match every type condition
+func (e *execution) doesFragmentTypeApply(obj interface{}, objType *schema.Object, typ string) bool {
+	if objType == nil {
+		if t, ok := obj.(resolver.Typed); ok {
+			return t.TypeName() == typ
+		}
+		return true
+	}
+
+	if objType.Name == typ {
+		return true
+	}
+	cond := e.schema.Type(typ)
+	return schema.IsAbstractType(cond) && e.schema.IsPossibleType(cond, objType)
+}
@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/resolver"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// valueFromAST converts a literal into the Go value handed to resolvers
+//
+// Variables are replaced with their value from vars, enums become their name
+func valueFromAST(v ast.Value, vars map[string]interface{}) interface{} {
+	switch {
+	case v.Variable != nil:
+		return vars[*v.Variable]
+	case v.Int != nil:
+		return *v.Int
+	case v.Float != nil:
+		return *v.Float
+	case v.String != nil:
+		return *v.String
+	case v.Bool != nil:
+		return *v.Bool
+	case v.Enum != nil:
+		return *v.Enum
+	case v.List != nil:
+		list := make([]interface{}, len(v.List))
+		for i, item := range v.List {
+			list[i] = valueFromAST(item, vars)
+		}
+		return list
+	case v.Object != nil:
+		obj := make(map[string]interface{}, len(v.Object))
+		for _, field := range v.Object {
+			obj[field.Name] = valueFromAST(field.Value, vars)
+		}
+		return obj
+	}
+
+	return nil
+}
+
+// argumentValues builds the Args for a field or directive
+//
+// Arguments given as a variable that was not provided are left out,
+// unless defs gives them a default value. Arguments with a definition are
+// coerced to its type
+func argumentValues(args ast.Arguments, defs schema.InputValueList, vars map[string]interface{}) (resolver.Args, error) {
+	if len(args) == 0 && len(defs) == 0 {
+		return nil, nil
+	}
+
+	out := make(resolver.Args, len(args))
+	for _, arg := range args {
+		if arg.Value.Variable != nil {
+			if _, ok := vars[*arg.Value.Variable]; !ok {
+				continue
+			}
+		}
+		out[arg.Name] = valueFromAST(arg.Value, vars)
+	}
+	for _, def := range defs {
+		v, ok := out[def.Name]
+		if !ok {
+			if def.DefaultValue == nil {
+				continue
+			}
+			v = valueFromAST(*def.DefaultValue, nil)
+		}
+		coerced, err := coerceValue(v, def.Type, def.Name)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s", err)
+		}
+		out[def.Name] = coerced
+	}
+	return out, nil
+}
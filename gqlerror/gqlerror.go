@@ -0,0 +1,82 @@
+package gqlerror
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Location is a position in a GraphQL document
+// Lines and columns start at 1
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an error in the shape described by the spec's Errors section
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Errorf creates an Error without a location or path
+func Errorf(format string, args ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorfAt creates an Error pointing at the given locations
+func ErrorfAt(locs []Location, format string, args ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: locs}
+}
+
+// Extender is implemented by errors that want to add entries
+// to the extensions of the Error they are wrapped in
+type Extender interface {
+	Extensions() map[string]interface{}
+}
+
+// Wrap turns err into an Error
+//
+// If err is, or wraps, an Error then that Error is returned as is
+func Wrap(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var gqlErr *Error
+	if errors.As(err, &gqlErr) {
+		return gqlErr
+	}
+
+	out := &Error{Message: err.Error()}
+	var ext Extender
+	if errors.As(err, &ext) {
+		out.Extensions = ext.Extensions()
+	}
+	return out
+}
+
+// Error formats the error as "line:column: message", followed by the path if there is one
+func (e *Error) Error() string {
+	var sb strings.Builder
+	if len(e.Locations) > 0 {
+		sb.WriteString(strconv.Itoa(e.Locations[0].Line))
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(e.Locations[0].Column))
+		sb.WriteString(": ")
+	}
+	sb.WriteString(e.Message)
+	if len(e.Path) > 0 {
+		sb.WriteString(" (path: ")
+		for i, p := range e.Path {
+			if i > 0 {
+				sb.WriteByte('.')
+			}
+			fmt.Fprint(&sb, p)
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
@@ -0,0 +1,57 @@
+package gqlerror_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dianelooney/graphql/gqlerror"
+)
+
+type codeError struct{}
+
+func (codeError) Error() string {
+	return "not allowed"
+}
+func (codeError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "FORBIDDEN"}
+}
+
+func TestJSON(t *testing.T) {
+	err := &gqlerror.Error{
+		Message:    "boom",
+		Locations:  []gqlerror.Location{{Line: 2, Column: 5}},
+		Path:       []interface{}{"a", 0, "b"},
+		Extensions: map[string]interface{}{"code": "X"},
+	}
+	out, _ := json.Marshal(err)
+	expected := `{"message":"boom","locations":[{"line":2,"column":5}],"path":["a",0,"b"],"extensions":{"code":"X"}}`
+	if string(out) != expected {
+		t.Errorf("Marshalled error is %s, expected %s", out, expected)
+	}
+	if err.Error() != "2:5: boom (path: a.0.b)" {
+		t.Errorf("Error() returned %q", err.Error())
+	}
+
+	out, _ = json.Marshal(gqlerror.Errorf("plain %d", 1))
+	if string(out) != `{"message":"plain 1"}` {
+		t.Errorf("Marshalled error is %s", out)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	orig := gqlerror.Errorf("original")
+	if gqlerror.Wrap(fmt.Errorf("wrapped: %w", orig)) != orig {
+		t.Errorf("Expected Wrap to return the wrapped *Error")
+	}
+	if gqlerror.Wrap(errors.New("plain")).Message != "plain" {
+		t.Errorf("Expected Wrap to keep the message of a plain error")
+	}
+	if ext := gqlerror.Wrap(codeError{}).Extensions; ext["code"] != "FORBIDDEN" {
+		t.Errorf("Expected Wrap to use the extensions of an Extender, got %v", ext)
+	}
+	if gqlerror.Wrap(nil) != nil {
+		t.Errorf("Expected Wrap(nil) to be nil")
+	}
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/executor"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/parser"
+	"github.com/dianelooney/graphql/resolver"
+)
+
+// Media types used for requests and responses
+const (
+	ContentTypeJSON            = "application/json"
+	ContentTypeGraphQL         = "application/graphql"
+	ContentTypeGraphQLResponse = "application/graphql-response+json"
+)
+
+// Request is a GraphQL request, as sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions"`
+}
+
+// Handler serves GraphQL over HTTP
+//
+// It accepts GET requests with the request in the query string, and POST
+// requests with either a JSON body or an application/graphql body holding
+// the query. Mutations are only allowed over POST.
+//
+// Responses follow the GraphQL over HTTP draft: clients that accept
+// application/graphql-response+json get a 400 status code whenever the
+// request could not be executed, others get application/json with a 200
+// status code for every well-formed request
+type Handler struct {
+	// Executor runs the requests, its Schema is used to validate them
+	Executor *executor.Executor
+
+	// Root is the value the top level fields of every operation are resolved from
+	Root resolver.ObjectContext
+
+	// Limits bound the queries the handler parses
+	// The zero value stands for parser.DefaultLimits
+	//
+	// MaxSize also bounds the body of POST requests, doubled for JSON bodies
+	// to leave room for escaping the query and for the variables. Larger
+	// bodies get a 413 status code
+	Limits parser.Limits
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	respType, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		http.Error(w, "Not Acceptable: supported response types are "+ContentTypeGraphQLResponse+" and "+ContentTypeJSON, http.StatusNotAcceptable)
+		return
+	}
+
+	limits := h.Limits
+	if limits == (parser.Limits{}) {
+		limits = parser.DefaultLimits
+	}
+
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		var err error
+		req, err = readQueryString(r)
+		if err != nil {
+			writeError(w, respType, http.StatusBadRequest, err)
+			return
+		}
+	case http.MethodPost:
+		var status int
+		var err error
+		req, status, err = readBody(w, r, limits.MaxSize)
+		if err != nil {
+			writeError(w, respType, status, err)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, respType, http.StatusMethodNotAllowed, gqlerror.Errorf("method %s is not allowed, use GET or POST", r.Method))
+		return
+	}
+
+	if req.Query == "" {
+		writeError(w, respType, http.StatusBadRequest, gqlerror.Errorf("request does not contain a query"))
+		return
+	}
+
+	p := parser.Parser{Limits: limits}
+	p.Init([]byte(req.Query))
+	doc := p.ParseQuery()
+	if errs := p.Errors(); len(errs) > 0 {
+		res := executor.Result{}
+		for _, err := range errs {
+			res.Errors = append(res.Errors, gqlerror.Wrap(err))
+		}
+		writeResult(w, respType, res)
+		return
+	}
+
+	if r.Method == http.MethodGet && isMutation(doc, req.OperationName) {
+		w.Header().Set("Allow", "POST")
+		writeError(w, respType, http.StatusMethodNotAllowed, gqlerror.Errorf("mutations can only be sent with POST"))
+		return
+	}
+
+	ex := h.Executor
+	if ex == nil {
+		ex = &executor.Executor{}
+	}
+	writeResult(w, respType, ex.Execute(r.Context(), doc, req.OperationName, req.Variables, h.Root))
+}
+
+// negotiate picks the response media type from an Accept header
+//
+// Each supported type gets the quality of the most specific media range
+// matching it, and the one with the highest quality wins, then the one
+// matched more specifically, then application/graphql-response+json. Only
+// application/json is matched by wildcards, and a quality of 0 excludes a
+// type
+func negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return ContentTypeJSON, true
+	}
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, supported := range []string{ContentTypeGraphQLResponse, ContentTypeJSON} {
+		q, specificity := quality(accept, supported)
+		if q > bestQ || q == bestQ && specificity > bestSpecificity {
+			best, bestQ, bestSpecificity = supported, q, specificity
+		}
+	}
+	return best, bestQ > 0
+}
+
+// quality returns the quality an Accept header gives mediaType and the
+// specificity of the media range it comes from: 2 for the type itself, 1
+// for application/* and 0 for */*, or -1 if no range matches it
+func quality(accept, mediaType string) (q float64, specificity int) {
+	specificity = -1
+	for _, part := range strings.Split(accept, ",") {
+		r, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		s := -1
+		switch {
+		case r == mediaType:
+			s = 2
+		case mediaType != ContentTypeJSON:
+		case r == "application/*":
+			s = 1
+		case r == "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+
+		rq := 1.0
+		if v, ok := params["q"]; ok {
+			rq, err = strconv.ParseFloat(v, 64)
+			if err != nil || rq < 0 || rq > 1 {
+				continue
+			}
+		}
+		q, specificity = rq, s
+	}
+	return q, specificity
+}
+
+func readQueryString(r *http.Request) (req Request, err error) {
+	q := r.URL.Query()
+	req.Query = q.Get("query")
+	req.OperationName = q.Get("operationName")
+	if v := q.Get("variables"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+			return req, gqlerror.Errorf("variables must be a JSON object: %v", err)
+		}
+	}
+	if v := q.Get("extensions"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Extensions); err != nil {
+			return req, gqlerror.Errorf("extensions must be a JSON object: %v", err)
+		}
+	}
+	return
+}
+
+// readBody reads the request from the body of a POST request, which must
+// not be larger than maxSize bytes, or twice that for JSON, unless maxSize
+// is zero
+func readBody(w http.ResponseWriter, r *http.Request, maxSize int) (req Request, status int, err error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return req, http.StatusUnsupportedMediaType, gqlerror.Errorf("missing or invalid Content-Type")
+	}
+	if mediaType == ContentTypeJSON {
+		maxSize *= 2
+	}
+	if maxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(maxSize))
+	}
+
+	switch mediaType {
+	case ContentTypeJSON:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if tooLarge(err) {
+				return req, http.StatusRequestEntityTooLarge, gqlerror.Errorf("body is larger than the limit of %d bytes", maxSize)
+			}
+			return req, http.StatusBadRequest, gqlerror.Errorf("body must be a JSON encoded request: %v", err)
+		}
+	case ContentTypeGraphQL:
+		body, err := io.ReadAll(r.Body)
+		if tooLarge(err) {
+			return req, http.StatusRequestEntityTooLarge, gqlerror.Errorf("body is larger than the limit of %d bytes", maxSize)
+		}
+		if err != nil {
+			return req, http.StatusBadRequest, gqlerror.Errorf("could not read body: %v", err)
+		}
+		req, err = readQueryString(r)
+		if err != nil {
+			return req, http.StatusBadRequest, err
+		}
+		req.Query = string(body)
+	default:
+		return req, http.StatusUnsupportedMediaType, gqlerror.Errorf("unsupported Content-Type %s", mediaType)
+	}
+	return req, http.StatusOK, nil
+}
+
+// tooLarge reports whether err comes from reading past the size limit of a
+// body
+func tooLarge(err error) bool {
+	var maxBytes *http.MaxBytesError
+	return errors.As(err, &maxBytes)
+}
+
+// isMutation reports whether the operation that would be run is a mutation
+func isMutation(doc ast.Document, operationName string) bool {
+	if operationName != "" {
+		op, ok := doc.Operations[operationName]
+		return ok && op.OpType == "mutation"
+	}
+	if doc.Operation != nil {
+		return doc.Operation.OpType == "mutation"
+	}
+	for _, op := range doc.Operations {
+		if op.OpType == "mutation" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, respType string, status int, err error) {
+	res := executor.Result{Errors: []*gqlerror.Error{gqlerror.Wrap(err)}}
+	out, _ := json.Marshal(res)
+	w.Header().Set("Content-Type", respType+"; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(out)
+}
+
+func writeResult(w http.ResponseWriter, respType string, res executor.Result) {
+	status := http.StatusOK
+	if respType == ContentTypeGraphQLResponse && !res.Executed() {
+		status = http.StatusBadRequest
+	}
+
+	out, err := json.Marshal(res)
+	if err != nil {
+		writeError(w, respType, http.StatusInternalServerError, gqlerror.Errorf("could not encode response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", respType+"; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(out)
+}
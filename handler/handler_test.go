@@ -0,0 +1,160 @@
+package handler_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dianelooney/graphql/executor"
+	"github.com/dianelooney/graphql/handler"
+	"github.com/dianelooney/graphql/parser"
+	"github.com/dianelooney/graphql/resolver"
+	"github.com/dianelooney/graphql/schema"
+)
+
+type root struct{}
+
+func (root) Resolve(field string, args resolver.Args) (interface{}, error) {
+	switch field {
+	case "hello":
+		if name, ok := args["name"].(string); ok {
+			return "hello " + name, nil
+		}
+		return "hello world", nil
+	case "bump":
+		return 1, nil
+	}
+	return nil, errors.New("missing field")
+}
+
+func newHandler(t *testing.T) *handler.Handler {
+	p := parser.Parser{}
+	p.Init([]byte(`
+	type Query { hello(name: String): String }
+	type Mutation { bump: Int }
+	`))
+	s, errs := schema.Build(p.ParseSchema())
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+	return &handler.Handler{
+		Executor: &executor.Executor{Schema: s},
+		Root:     resolver.AdaptObject(root{}),
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := newHandler(t)
+	tests := []struct {
+		method      string
+		target      string
+		contentType string
+		accept      string
+		body        string
+
+		status       int
+		respType     string
+		expectedBody string
+	}{
+		{"POST", "/", "application/json", "", `{"query":"{ hello }"}`,
+			200, "application/json", `{"data":{"hello":"hello world"}}`},
+		{"POST", "/", "application/json; charset=utf-8", "application/graphql-response+json", `{"query":"query ($n: String) { hello(name: $n) }","variables":{"n":"bob"}}`,
+			200, "application/graphql-response+json", `{"data":{"hello":"hello bob"}}`},
+		{"POST", "/?operationName=B", "application/graphql", "application/json", `query A { a: hello } query B { b: hello }`,
+			200, "application/json", `{"data":{"b":"hello world"}}`},
+		{"GET", "/?" + url.Values{"query": {"{ hello(name: \"x\") }"}}.Encode(), "", "*/*", "",
+			200, "application/json", `{"data":{"hello":"hello x"}}`},
+		{"GET", "/?" + url.Values{"query": {"mutation { bump }"}}.Encode(), "", "", "",
+			405, "application/json", `{"errors":[{"message":"mutations can only be sent with POST"}]}`},
+		{"POST", "/", "application/json", "", `{"query":"mutation { bump }"}`,
+			200, "application/json", `{"data":{"bump":1}}`},
+		{"POST", "/", "application/json", "application/graphql-response+json", `{"query":"query ($n: String!) { hello(name: $n) }"}`,
+			400, "application/graphql-response+json", `{"errors":[{"message":"variable \"$n\" of required type \"String!\" was not provided"}]}`},
+		{"POST", "/", "application/json", "", `{"query":"{ nope }"}`,
+			200, "application/json", `{"errors":[{"message":"cannot query field \"nope\" on type \"Query\"","locations":[{"line":1,"column":3}]}]}`},
+		{"POST", "/", "application/json", "application/graphql-response+json", `{"query":"{ nope }"}`,
+			400, "application/graphql-response+json", `{"errors":[{"message":"cannot query field \"nope\" on type \"Query\"","locations":[{"line":1,"column":3}]}]}`},
+		{"POST", "/", "application/json", "", `{"query":"{ hello"}`,
+			200, "application/json", `{"errors":[{"message":"expected to find a different token","locations":[{"line":1,"column":8}]}]}`},
+		{"POST", "/", "application/json", "", `{"query":"{ hello(name: ` + strings.Repeat("[", 200) + `"}`,
+			200, "application/json", `{"errors":[{"message":"document is nested deeper than the limit of 100 levels","locations":[{"line":1,"column":114}]}]}`},
+		{"POST", "/", "application/graphql", "", `{ hello } type Query { x: Int }`,
+			200, "application/json", `{"errors":[{"message":"an executable document can only contain operations and fragments, found type Query","locations":[{"line":1,"column":11}]}]}`},
+		{"POST", "/", "application/json", "", `not json`,
+			400, "application/json", ""},
+		{"POST", "/", "application/json", "", `{}`,
+			400, "application/json", `{"errors":[{"message":"request does not contain a query"}]}`},
+		{"POST", "/", "text/plain", "", `{ hello }`,
+			415, "application/json", `{"errors":[{"message":"unsupported Content-Type text/plain"}]}`},
+		{"PUT", "/", "application/json", "", `{"query":"{ hello }"}`,
+			405, "application/json", `{"errors":[{"message":"method PUT is not allowed, use GET or POST"}]}`},
+		{"POST", "/", "application/json", "text/html", `{"query":"{ hello }"}`,
+			406, "text/plain", ""},
+		{"POST", "/", "application/json", "application/graphql-response+json;q=0.1, application/json", `{"query":"{ hello }"}`,
+			200, "application/json", `{"data":{"hello":"hello world"}}`},
+		{"POST", "/", "application/json", "application/json;q=0.5, application/graphql-response+json;q=0.9", `{"query":"{ hello }"}`,
+			200, "application/graphql-response+json", `{"data":{"hello":"hello world"}}`},
+		{"POST", "/", "application/json", "*/*;q=0.5, application/graphql-response+json;q=0.5", `{"query":"{ hello }"}`,
+			200, "application/graphql-response+json", `{"data":{"hello":"hello world"}}`},
+		{"POST", "/", "application/json", "application/json;q=0.0, */*", `{"query":"{ hello }"}`,
+			406, "text/plain", ""},
+		{"POST", "/", "application/json", "application/*;q=0", `{"query":"{ hello }"}`,
+			406, "text/plain", ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		name := test.method + " " + test.target + " " + test.body
+		if resp.StatusCode != test.status {
+			t.Errorf("%s: expected status %d, got %d (%s)", name, test.status, resp.StatusCode, body)
+		}
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, test.respType) {
+			t.Errorf("%s: expected Content-Type %s, got %s", name, test.respType, ct)
+		}
+		if test.expectedBody != "" && string(body) != test.expectedBody {
+			t.Errorf("%s: expected body\n%s\ngot\n%s", name, test.expectedBody, body)
+		}
+	}
+}
+
+func TestHandlerBodyLimit(t *testing.T) {
+	h := newHandler(t)
+	h.Limits = parser.Limits{MaxSize: 100}
+	tests := []struct {
+		contentType string
+		body        string
+		status      int
+	}{
+		{"application/graphql", "{ hello }" + strings.Repeat(" ", 91), 200},
+		{"application/graphql", "{ hello }" + strings.Repeat(" ", 92), 413},
+		{"application/json", strings.Repeat(" ", 179) + `{"query":"{ hello }"}`, 200},
+		{"application/json", strings.Repeat(" ", 180) + `{"query":"{ hello }"}`, 413},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != test.status {
+			t.Errorf("POST of %d bytes of %s: expected status %d, got %d (%s)", len(test.body), test.contentType, test.status, resp.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package normalize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/printer"
+)
+
+// Fingerprinter prints operations in a canonical form, so that operations
+// only differing in formatting share a fingerprint
+//
+// The canonical form is the operation followed by the fragments it spreads,
+// sorted by name, without comments, printed by the printer package. Fields
+// are sorted by name, then alias, before the fragment spreads and inline
+// fragments, except the top level fields of mutations, which run in the
+// order they are written. Arguments, object fields and variable definitions
+// are sorted by name
+type Fingerprinter struct {
+	// HideLiterals replaces the strings and numbers in values with "" and 0,
+	// so that operations only differing in their literals share a
+	// fingerprint. Lists and objects keep their items and fields, so that
+	// the variables used within them still count
+	HideLiterals bool
+}
+
+// Fingerprint returns the canonical form of op with Fingerprinter{}
+func Fingerprint(op ast.Operation, fragments map[string]ast.FragmentDef) (canonical string, hash string) {
+	return Fingerprinter{}.Fingerprint(op, fragments)
+}
+
+// Fingerprint returns the canonical form of op, using the fragments it
+// spreads from fragments, and its SHA-256 hash in hexadecimal
+func (f Fingerprinter) Fingerprint(op ast.Operation, fragments map[string]ast.FragmentDef) (canonical string, hash string) {
+	serial := serialFragments(&op, fragments)
+	c := ast.Operation{
+		OpType:       op.OpType,
+		Name:         op.Name,
+		Directives:   f.directives(op.Directives),
+		SelectionSet: f.selectionSet(op.SelectionSet, op.OpType == "mutation"),
+	}
+	for _, v := range op.Variables {
+		def := ast.VariableDef{
+			Name:       v.Name,
+			Type:       v.Type,
+			Directives: f.directives(v.Directives),
+		}
+		if v.DefaultValue != nil {
+			value := f.value(*v.DefaultValue)
+			def.DefaultValue = &value
+		}
+		c.Variables = append(c.Variables, def)
+	}
+	sort.SliceStable(c.Variables, func(i, j int) bool { return c.Variables[i].Name < c.Variables[j].Name })
+
+	doc := ast.Document{Definitions: []ast.Definition{{Operation: &c}}}
+	for _, frag := range spreadFragments(&op, fragments) {
+		doc.Definitions = append(doc.Definitions, ast.Definition{Fragment: &ast.FragmentDef{
+			Name:         frag.Name,
+			Type:         frag.Type,
+			Directives:   f.directives(frag.Directives),
+			SelectionSet: f.selectionSet(frag.SelectionSet, serial[frag.Name]),
+		}})
+	}
+
+	canonical = printer.Sprint(doc)
+	sum := sha256.Sum256([]byte(canonical))
+	return canonical, hex.EncodeToString(sum[:])
+}
+
+// selectionSet returns a copy of sel without comments, sorted unless its
+// fields run serially
+func (f Fingerprinter) selectionSet(sel []ast.Selection, serial bool) []ast.Selection {
+	type keyed struct {
+		kind, name, alias, text string
+		ast.Selection
+	}
+	out := make([]keyed, 0, len(sel))
+	for _, s := range sel {
+		var k keyed
+		switch {
+		case s.Field != nil:
+			k.Selection.Field = &ast.Field{
+				Alias:        s.Field.Alias,
+				Name:         s.Field.Name,
+				Arguments:    f.arguments(s.Field.Arguments),
+				Directives:   f.directives(s.Field.Directives),
+				SelectionSet: f.selectionSet(s.Field.SelectionSet, false),
+			}
+			k.kind, k.name = "field", s.Field.Name
+			if s.Field.Alias != nil {
+				k.alias = *s.Field.Alias
+			}
+		case s.FragmentSpread != nil:
+			k.Selection.FragmentSpread = &ast.FragmentSpread{
+				Name:       s.FragmentSpread.Name,
+				Directives: f.directives(s.FragmentSpread.Directives),
+			}
+			k.kind, k.name = "fragment spread", s.FragmentSpread.Name
+		case s.InlineFragment != nil:
+			k.Selection.InlineFragment = &ast.InlineFragment{
+				Type:         s.InlineFragment.Type,
+				Directives:   f.directives(s.InlineFragment.Directives),
+				SelectionSet: f.selectionSet(s.InlineFragment.SelectionSet, serial),
+			}
+			k.kind = "inline fragment"
+			if s.InlineFragment.Type != nil {
+				k.name = *s.InlineFragment.Type
+			}
+		}
+		k.text = printer.Sprint(k.Selection)
+		out = append(out, k)
+	}
+
+	if !serial {
+		sort.SliceStable(out, func(i, j int) bool {
+			a, b := out[i], out[j]
+			switch {
+			case a.kind != b.kind:
+				return a.kind < b.kind
+			case a.name != b.name:
+				return a.name < b.name
+			case a.alias != b.alias:
+				return a.alias < b.alias
+			}
+			return a.text < b.text
+		})
+	}
+
+	var result []ast.Selection
+	for _, k := range out {
+		result = append(result, k.Selection)
+	}
+	return result
+}
+
+// serialFragments returns the names of the fragments spread at the top
+// level of a mutation, directly or through inline fragments and other
+// fragments, whose top level fields run serially
+func serialFragments(op *ast.Operation, fragments map[string]ast.FragmentDef) map[string]bool {
+	serial := make(map[string]bool)
+	if op.OpType != "mutation" {
+		return serial
+	}
+	var visit func(sel []ast.Selection)
+	visit = func(sel []ast.Selection) {
+		for _, s := range sel {
+			switch {
+			case s.InlineFragment != nil:
+				visit(s.InlineFragment.SelectionSet)
+			case s.FragmentSpread != nil && !serial[s.FragmentSpread.Name]:
+				serial[s.FragmentSpread.Name] = true
+				visit(fragments[s.FragmentSpread.Name].SelectionSet)
+			}
+		}
+	}
+	visit(op.SelectionSet)
+	return serial
+}
+
+// directives returns a copy of directives without comments, keeping their
+// order
+func (f Fingerprinter) directives(directives []ast.Directive) (out []ast.Directive) {
+	for _, d := range directives {
+		out = append(out, ast.Directive{Name: d.Name, Arguments: f.arguments(d.Arguments)})
+	}
+	return out
+}
+
+// arguments returns a copy of args without comments, sorted by name
+func (f Fingerprinter) arguments(args ast.Arguments) ast.Arguments {
+	out := make(ast.Arguments, 0, len(args))
+	for _, arg := range args {
+		out = append(out, ast.Argument{Name: arg.Name, Value: f.value(arg.Value)})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// value returns a copy of v without comments, with its literals hidden when
+// HideLiterals is set
+func (f Fingerprinter) value(v ast.Value) ast.Value {
+	out := ast.Value{Variable: v.Variable, Bool: v.Bool, IsNull: v.IsNull, Enum: v.Enum}
+	if f.HideLiterals {
+		zero, empty := 0, ""
+		switch {
+		case v.Int != nil, v.Float != nil:
+			out.Int = &zero
+		case v.String != nil:
+			out.String = &empty
+		}
+	} else {
+		out.Int, out.Float, out.String = v.Int, v.Float, v.String
+	}
+	if v.List != nil {
+		out.List = make([]ast.Value, 0, len(v.List))
+		for _, item := range v.List {
+			out.List = append(out.List, f.value(item))
+		}
+	}
+	if v.Object != nil {
+		out.Object = f.arguments(v.Object)
+	}
+	return out
+}
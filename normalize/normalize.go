@@ -0,0 +1,358 @@
+// Package normalize rewrites operations into a simpler form that executes
+// the same way, for logging, caching and forwarding them
+//
+// Named fragment spreads are inlined, inline fragments that always apply are
+// merged into the selection set around them and the ones that never apply
+// are dropped, @skip and @include directives with constant arguments are
+// applied, identical fields are merged, and the variables that are no
+// longer used are removed
+package normalize
+
+import (
+	"sort"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/printer"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// Operation returns a document holding op normalized, using the fragments
+// it spreads from fragments
+//
+// The type conditions of fragments are checked against s, which may be nil
+// to keep every fragment with a type condition as an inline fragment. The
+// document also holds the fragments that could not be inlined, because they
+// are missing or spread within themselves. Directives on inlined fragment
+// definitions are dropped, and the selections of a fragment inlined several
+// times may be shared
+func Operation(s *schema.Schema, op ast.Operation, fragments map[string]ast.FragmentDef) ast.Document {
+	n := &normalizer{
+		schema:    s,
+		fragments: fragments,
+		visiting:  make(map[string]bool),
+		collected: make(map[fragmentKey][]ast.Selection),
+	}
+
+	var root schema.Type
+	if s != nil {
+		if obj := s.RootType(op.OpType); obj != nil {
+			root = obj
+		}
+	}
+	op.SelectionSet = n.selectionSet(root, op.SelectionSet)
+
+	doc := ast.Document{
+		Node:       op.Node,
+		Operations: make(map[string]ast.Operation),
+		Fragments:  make(map[string]ast.FragmentDef),
+		Types:      make(map[string]ast.TypeDef),
+		Directives: make(map[string]ast.DirectiveDef),
+	}
+	kept := spreadFragments(&op, fragments)
+	op.Variables = usedVariables(&op, kept)
+	if op.Name != nil {
+		doc.Operations[*op.Name] = op
+	} else {
+		doc.Operation = &op
+	}
+	doc.Definitions = append(doc.Definitions, ast.Definition{Operation: &op})
+	for _, frag := range kept {
+		frag := frag
+		doc.Fragments[frag.Name] = frag
+		doc.Definitions = append(doc.Definitions, ast.Definition{Fragment: &frag})
+	}
+	return doc
+}
+
+type normalizer struct {
+	schema    *schema.Schema
+	fragments map[string]ast.FragmentDef
+
+	// visiting holds the fragments being inlined, and cycles counts the
+	// spreads kept because their fragment was being inlined
+	visiting map[string]bool
+	cycles   int
+
+	// collected holds the selections of the fragments collected so far, so
+	// that a fragment spread many times is only collected once per type
+	collected map[fragmentKey][]ast.Selection
+}
+
+// fragmentKey identifies the selections of a fragment collected on a type
+type fragmentKey struct {
+	name string
+	typ  schema.Type
+}
+
+// selectionSet returns sel normalized, where parent is the type it selects
+// from, or nil if unknown
+func (n *normalizer) selectionSet(parent schema.Type, sel []ast.Selection) []ast.Selection {
+	var out []ast.Selection
+	n.collect(parent, sel, &out)
+	return n.merge(parent, out)
+}
+
+// collect appends the normalized selections of sel to out, inlining the
+// fragments that always apply
+func (n *normalizer) collect(parent schema.Type, sel []ast.Selection, out *[]ast.Selection) {
+	for _, s := range sel {
+		switch {
+		case s.Field != nil:
+			directives, ok := include(s.Field.Directives)
+			if !ok {
+				continue
+			}
+			field := *s.Field
+			field.Directives = directives
+			if len(field.SelectionSet) > 0 {
+				field.SelectionSet = n.selectionSet(n.fieldType(parent, field.Name), field.SelectionSet)
+			}
+			*out = append(*out, ast.Selection{Field: &field})
+
+		case s.InlineFragment != nil:
+			directives, ok := include(s.InlineFragment.Directives)
+			if !ok {
+				continue
+			}
+			n.fragment(parent, s.InlineFragment.Node, "", s.InlineFragment.Type, directives, s.InlineFragment.SelectionSet, out)
+
+		case s.FragmentSpread != nil:
+			directives, ok := include(s.FragmentSpread.Directives)
+			if !ok {
+				continue
+			}
+			frag, found := n.fragments[s.FragmentSpread.Name]
+			if !found || n.visiting[frag.Name] {
+				if found {
+					n.cycles++
+				}
+				spread := *s.FragmentSpread
+				spread.Directives = directives
+				*out = append(*out, ast.Selection{FragmentSpread: &spread})
+				continue
+			}
+			typeName := frag.Type
+			n.fragment(parent, s.FragmentSpread.Node, frag.Name, &typeName, directives, frag.SelectionSet, out)
+		}
+	}
+}
+
+// fragment appends the selections of a fragment on typeName to out, either
+// directly when it always applies, or as an inline fragment when it might.
+// The name of a named fragment is given, and is empty for inline fragments
+func (n *normalizer) fragment(parent schema.Type, node ast.Node, name string, typeName *string, directives []ast.Directive, sel []ast.Selection, out *[]ast.Selection) {
+	cond := parent
+	if typeName != nil {
+		cond = nil
+		if n.schema != nil {
+			cond = n.schema.Type(*typeName)
+		}
+	}
+
+	switch {
+	case len(directives) == 0 && n.alwaysApplies(parent, typeName, cond):
+		*out = append(*out, n.collectFragment(parent, name, sel)...)
+	case parent != nil && cond != nil && !n.schema.TypesOverlap(parent, cond):
+	default:
+		*out = append(*out, ast.Selection{InlineFragment: &ast.InlineFragment{
+			Node:         node,
+			Type:         typeName,
+			Directives:   directives,
+			SelectionSet: n.merge(cond, n.collectFragment(cond, name, sel)),
+		}})
+	}
+}
+
+// collectFragment returns the normalized selections of sel collected on
+// typ, reusing them when the fragment called name was already collected on
+// typ. Selections that kept a spread because of a cycle are not reused, as
+// they depend on the fragments being inlined
+func (n *normalizer) collectFragment(typ schema.Type, name string, sel []ast.Selection) (out []ast.Selection) {
+	if name == "" {
+		n.collect(typ, sel, &out)
+		return out
+	}
+	key := fragmentKey{name, typ}
+	if out, ok := n.collected[key]; ok {
+		return out
+	}
+
+	cycles := n.cycles
+	n.visiting[name] = true
+	n.collect(typ, sel, &out)
+	delete(n.visiting, name)
+	if n.cycles == cycles {
+		n.collected[key] = out
+	}
+	return out
+}
+
+// alwaysApplies reports whether a fragment on typeName, resolved as cond,
+// applies to every object selected from parent
+func (n *normalizer) alwaysApplies(parent schema.Type, typeName *string, cond schema.Type) bool {
+	if typeName == nil {
+		return true
+	}
+	if parent == nil || cond == nil {
+		return false
+	}
+	if parent == cond {
+		return true
+	}
+	obj, ok := parent.(*schema.Object)
+	return ok && n.schema.IsPossibleType(cond, obj)
+}
+
+// fieldType returns the named type of the field called name on parent, or
+// nil if unknown
+func (n *normalizer) fieldType(parent schema.Type, name string) schema.Type {
+	if parent == nil {
+		return nil
+	}
+	if def := n.schema.FieldDef(parent, name); def != nil {
+		return schema.NamedType(def.Type)
+	}
+	return nil
+}
+
+// merge merges the fields of sel with the same response key, arguments and
+// directives, and the identical inline fragments and fragment spreads
+func (n *normalizer) merge(parent schema.Type, sel []ast.Selection) []ast.Selection {
+	var out []ast.Selection
+	index := make(map[string]int)
+	merged := make(map[int]bool)
+
+	for _, s := range sel {
+		key := selectionKey(s)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(out)
+			out = append(out, s)
+			continue
+		}
+
+		switch {
+		case s.Field != nil:
+			field := *out[i].Field
+			field.SelectionSet = append(field.SelectionSet[:len(field.SelectionSet):len(field.SelectionSet)], s.Field.SelectionSet...)
+			out[i] = ast.Selection{Field: &field}
+			merged[i] = true
+		case s.InlineFragment != nil:
+			frag := *out[i].InlineFragment
+			frag.SelectionSet = append(frag.SelectionSet[:len(frag.SelectionSet):len(frag.SelectionSet)], s.InlineFragment.SelectionSet...)
+			out[i] = ast.Selection{InlineFragment: &frag}
+			merged[i] = true
+		}
+	}
+
+	for i := range merged {
+		switch s := out[i]; {
+		case s.Field != nil:
+			s.Field.SelectionSet = n.merge(n.fieldType(parent, s.Field.Name), s.Field.SelectionSet)
+		case s.InlineFragment != nil:
+			cond := parent
+			if s.InlineFragment.Type != nil && n.schema != nil {
+				cond = n.schema.Type(*s.InlineFragment.Type)
+			}
+			s.InlineFragment.SelectionSet = n.merge(cond, s.InlineFragment.SelectionSet)
+		}
+	}
+	return out
+}
+
+// selectionKey returns a key shared by the selections that merge: the
+// fields with the same response key, name, arguments and directives, and
+// the fragments with the same type condition or name and directives. It is
+// the selection printed without its selection set or comments, with the
+// arguments of fields sorted by name
+func selectionKey(s ast.Selection) string {
+	switch {
+	case s.Field != nil:
+		args := append(ast.Arguments(nil), s.Field.Arguments...)
+		sort.SliceStable(args, func(i, j int) bool { return args[i].Name < args[j].Name })
+		s = ast.Selection{Field: &ast.Field{
+			Alias:      s.Field.Alias,
+			Name:       s.Field.Name,
+			Arguments:  args,
+			Directives: s.Field.Directives,
+		}}
+	case s.InlineFragment != nil:
+		s = ast.Selection{InlineFragment: &ast.InlineFragment{
+			Type:       s.InlineFragment.Type,
+			Directives: s.InlineFragment.Directives,
+		}}
+	case s.FragmentSpread != nil:
+		s = ast.Selection{FragmentSpread: &ast.FragmentSpread{
+			Name:       s.FragmentSpread.Name,
+			Directives: s.FragmentSpread.Directives,
+		}}
+	}
+	return printer.Sprint(s)
+}
+
+// include applies the @skip and @include directives whose argument is a
+// constant, reporting whether the selection is included, and returns the
+// other directives
+func include(directives []ast.Directive) ([]ast.Directive, bool) {
+	var kept []ast.Directive
+	for _, d := range directives {
+		if d.Name == "skip" || d.Name == "include" {
+			if v, ok := d.Arguments.Get("if"); ok && v.Bool != nil {
+				if *v.Bool == (d.Name == "skip") {
+					return nil, false
+				}
+				continue
+			}
+		}
+		kept = append(kept, d)
+	}
+	return kept, true
+}
+
+// spreadFragments returns the fragments spread within op, directly or
+// through other fragments, sorted by name
+func spreadFragments(op *ast.Operation, fragments map[string]ast.FragmentDef) (spread []ast.FragmentDef) {
+	seen := make(map[string]bool)
+	var collect func(node interface{})
+	collect = func(node interface{}) {
+		ast.Inspect(node, func(node interface{}) bool {
+			s, ok := node.(*ast.FragmentSpread)
+			if !ok || seen[s.Name] {
+				return true
+			}
+			seen[s.Name] = true
+			if frag, ok := fragments[s.Name]; ok {
+				spread = append(spread, frag)
+				collect(&frag)
+			}
+			return true
+		})
+	}
+	collect(op)
+
+	sort.Slice(spread, func(i, j int) bool { return spread[i].Name < spread[j].Name })
+	return spread
+}
+
+// usedVariables returns the variable definitions of op that are used within
+// it or within fragments
+func usedVariables(op *ast.Operation, fragments []ast.FragmentDef) (vars []ast.VariableDef) {
+	used := make(map[string]bool)
+	uses := func(node interface{}) bool {
+		if v, ok := node.(*ast.Value); ok && v.Variable != nil {
+			used[*v.Variable] = true
+		}
+		return true
+	}
+	ast.Inspect(op, uses)
+	for i := range fragments {
+		ast.Inspect(&fragments[i], uses)
+	}
+
+	for _, v := range op.Variables {
+		if used[v.Name] {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
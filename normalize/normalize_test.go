@@ -0,0 +1,310 @@
+package normalize_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/normalize"
+	"github.com/dianelooney/graphql/parser"
+	"github.com/dianelooney/graphql/printer"
+	"github.com/dianelooney/graphql/schema"
+)
+
+const testSchema = `
+type Query { pet(id: ID): Pet dog: Dog node: Node }
+interface Pet { name: String owner: Human }
+interface Node { id: ID }
+type Dog implements Pet { name: String owner: Human barks: Boolean }
+type Cat implements Pet { name: String owner: Human meows: Boolean }
+type Human implements Node { id: ID name: String }
+`
+
+func parse(t *testing.T, src string) ast.Document {
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+	return doc
+}
+
+func expectNormalized(t *testing.T, s *schema.Schema, src string, expected string) {
+	doc := parse(t, src)
+	var op ast.Operation
+	for _, def := range doc.Definitions {
+		if def.Operation != nil {
+			op = *def.Operation
+			break
+		}
+	}
+	before := printer.Sprint(doc)
+	out := printer.Sprint(normalize.Operation(s, op, doc.Fragments))
+	if out != expected {
+		t.Errorf("Normalizing\n%s\nreturned\n%s\nexpected\n%s", src, out, expected)
+	}
+	if after := printer.Sprint(doc); after != before {
+		t.Errorf("Expected normalizing not to modify the document, it became\n%s", after)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	s, errs := schema.Build(parse(t, testSchema))
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	// Fragments that always apply are inlined, and fields merged
+	expectNormalized(t, s, `
+	query Q($id: ID) { pet(id: $id) { name ...P owner { id } } dog { ...P } }
+	fragment P on Pet { name owner { name } }
+	`, `query Q($id: ID) {
+  pet(id: $id) {
+    name
+    owner {
+      name
+      id
+    }
+  }
+  dog {
+    name
+    owner {
+      name
+    }
+  }
+}
+`)
+
+	// Fragments that might apply become inline fragments, and the ones that
+	// never apply are dropped
+	expectNormalized(t, s, `
+	{ pet { ...D ... on Cat { meows } ... on Human { name } ... { name } } node { ...H } }
+	fragment D on Dog { barks }
+	fragment H on Human { name }
+	`, `{
+  pet {
+    ... on Dog {
+      barks
+    }
+    ... on Cat {
+      meows
+    }
+    name
+  }
+  node {
+    ... on Human {
+      name
+    }
+  }
+}
+`)
+
+	// Constant @skip and @include are applied, variables no longer used are
+	// removed
+	expectNormalized(t, s, `
+	query ($a: Boolean, $b: Boolean, $id: ID) {
+		pet(id: $id) @skip(if: true) { name }
+		dog @include(if: true) { name @include(if: false) barks @skip(if: $a) }
+		x: pet(id: 1) { ... on Dog @include(if: $b) { barks } }
+		x: pet(id: 1) @skip(if: false) { name }
+	}
+	`, `query($a: Boolean, $b: Boolean) {
+  dog {
+    barks @skip(if: $a)
+  }
+  x: pet(id: 1) {
+    ... on Dog @include(if: $b) {
+      barks
+    }
+    name
+  }
+}
+`)
+
+	// Fragments spread within themselves are kept
+	expectNormalized(t, s, `
+	{ dog { ...A } }
+	fragment A on Dog { name ...A }
+	fragment Unused on Dog { name }
+	`, `{
+  dog {
+    name
+    ...A
+  }
+}
+
+fragment A on Dog {
+  name
+  ...A
+}
+`)
+
+	// Without a schema, type conditions are kept
+	expectNormalized(t, nil, `
+	{ dog { ...D ... { name } } }
+	fragment D on Dog { barks }
+	`, `{
+  dog {
+    ... on Dog {
+      barks
+    }
+    name
+  }
+}
+`)
+}
+
+func operation(t *testing.T, src string) (ast.Operation, map[string]ast.FragmentDef) {
+	doc := parse(t, src)
+	for _, def := range doc.Definitions {
+		if def.Operation != nil {
+			return *def.Operation, doc.Fragments
+		}
+	}
+	t.Fatalf("No operation in %s", src)
+	return ast.Operation{}, nil
+}
+
+func TestFingerprint(t *testing.T) {
+	canonical, hash := normalize.Fingerprint(operation(t, `
+	# a comment
+	query Q($b: Int, $a: String = "x") {
+		pet(id: 1) { owner { name, id } ...P name }
+		alias: dog @skip(if: $b) { name }
+		dog { barks }
+	}
+	fragment P on Pet { name }
+	fragment Unused on Pet { name }
+	`))
+	expected := `query Q($a: String = "x", $b: Int) {
+  dog {
+    barks
+  }
+  alias: dog @skip(if: $b) {
+    name
+  }
+  pet(id: 1) {
+    name
+    owner {
+      id
+      name
+    }
+    ...P
+  }
+}
+
+fragment P on Pet {
+  name
+}
+`
+	if canonical != expected {
+		t.Errorf("Fingerprint returned\n%s\nexpected\n%s", canonical, expected)
+	}
+	if len(hash) != 64 {
+		t.Errorf("Expected a hexadecimal SHA-256 hash, got %s", hash)
+	}
+
+	same := []string{
+		`query Q($a:String="x" $b:Int){dog{barks} pet(id:1){name ...P owner{id name}} alias:dog@skip(if:$b){name}} fragment P on Pet{name}`,
+		`query Q($b: Int, $a: String = "x") {
+			alias: dog @skip(if: $b) { name }
+			pet(id: 1) { ...P, name, owner { id, name } } # comment
+			dog { barks }
+		}
+		fragment P on Pet { name }`,
+	}
+	for _, src := range same {
+		if _, other := normalize.Fingerprint(operation(t, src)); other != hash {
+			t.Errorf("Expected %s to have the same fingerprint", src)
+		}
+	}
+
+	different := []string{
+		`query Q($a: String = "x", $b: Int) { dog { barks } alias: dog @skip(if: $b) { name } pet(id: 2) { name owner { id name } ...P } } fragment P on Pet { name }`,
+		`query Q($a: String = "x", $b: Int) { dog { barks } alias: dog @skip(if: $b) { name } pet(id: 1) { name owner { id name } ...P } } fragment P on Pet { owner { id } }`,
+	}
+	for _, src := range different {
+		if _, other := normalize.Fingerprint(operation(t, src)); other == hash {
+			t.Errorf("Expected %s to have a different fingerprint", src)
+		}
+	}
+
+	// the top level fields of mutations run in order
+	mutations := []struct {
+		a, b string
+		same bool
+	}{
+		{`mutation { b a }`, `mutation { a b }`, false},
+		{`mutation { ...M } fragment M on Mutation { b a }`, `mutation { ...M } fragment M on Mutation { a b }`, false},
+		{`mutation { ... { b a } }`, `mutation { ... { a b } }`, false},
+		{`mutation { a { y x } }`, `mutation { a { x y } }`, true},
+	}
+	for _, m := range mutations {
+		_, a := normalize.Fingerprint(operation(t, m.a))
+		_, b := normalize.Fingerprint(operation(t, m.b))
+		if (a == b) != m.same {
+			t.Errorf("Expected %s and %s to have the same fingerprint: %v", m.a, m.b, m.same)
+		}
+	}
+
+	f := normalize.Fingerprinter{HideLiterals: true}
+	canonical, hash = f.Fingerprint(operation(t, `{ pet(id: "a") { name } dog @include(if: true) { o: owner(n: [1], m: {x: 1.5}, e: ENUM) { name } } }`))
+	expected = `{
+  dog @include(if: true) {
+    o: owner(e: ENUM, m: {x: 0}, n: [0]) {
+      name
+    }
+  }
+  pet(id: "") {
+    name
+  }
+}
+`
+	if canonical != expected {
+		t.Errorf("Fingerprint hiding literals returned\n%s\nexpected\n%s", canonical, expected)
+	}
+	if _, other := f.Fingerprint(operation(t, `{ pet(id: "b") { name } dog @include(if: true) { o: owner(n: [2], m: {x: 2}, e: ENUM) { name } } }`)); other != hash {
+		t.Errorf("Expected operations only differing in their literals to have the same fingerprint")
+	}
+	_, a := f.Fingerprint(operation(t, `query ($x: Int, $y: Int) { dog { o: owner(m: {a: $x}, n: [$y]) { name } } }`))
+	_, b := f.Fingerprint(operation(t, `query ($x: Int, $y: Int) { dog { o: owner(m: {b: $y}, n: [$x]) { name } } }`))
+	if a == b {
+		t.Errorf("Expected hiding literals to keep the variables used in lists and objects")
+	}
+}
+
+func TestRepeatedFragments(t *testing.T) {
+	s, errs := schema.Build(parse(t, `type Query { q: Query a: Int }`))
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	var src strings.Builder
+	src.WriteString("{ ...F0 }\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&src, "fragment F%d on Query { q { ...F%d } q { ...F%d } }\n", i, i+1, i+1)
+	}
+	src.WriteString("fragment F30 on Query { a }\n")
+	op, fragments := operation(t, src.String())
+	flat, _ := operation(t, "{ "+strings.Repeat("q { ", 30)+"a"+strings.Repeat(" }", 31))
+	expected := printer.Sprint(normalize.Operation(s, flat, nil))
+
+	done := make(chan string)
+	go func() {
+		doc := normalize.Operation(s, op, fragments)
+		normalize.Fingerprint(*doc.Operation, doc.Fragments)
+		normalize.Fingerprint(op, fragments)
+		done <- printer.Sprint(doc)
+	}()
+	select {
+	case out := <-done:
+		if out != expected {
+			t.Errorf("Normalizing repeated fragments returned\n%s\nexpected\n%s", out, expected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Normalizing did not return within 5s")
+	}
+}
@@ -1,11 +1,117 @@
 package parser
 
 import (
-	"errors"
-
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
 	"github.com/dianelooney/graphql/scanner"
 )
 
+// errorAt records an error pointing at pos, unless the same error was
+// already recorded there. Once MaxErrors errors are recorded, it records a
+// last one and stops parsing
+func (p *Parser) errorAt(pos scanner.Position, msg string) {
+	key := errorKey{p.location(pos), msg}
+	if p.reported[key] {
+		return
+	}
+	if len(p.errors) >= MaxErrors {
+		p.errors = append(p.errors, gqlerror.ErrorfAt([]gqlerror.Location{key.loc}, "too many errors"))
+		panic(abort{})
+	}
+	if p.reported == nil {
+		p.reported = make(map[errorKey]bool)
+	}
+	p.reported[key] = true
+	p.errors = append(p.errors, gqlerror.ErrorfAt([]gqlerror.Location{key.loc}, "%s", msg))
+}
+
+// errorKey identifies the errors recorded by errorAt
+type errorKey struct {
+	loc gqlerror.Location
+	msg string
+}
+
+// errorNext records a syntax error pointing at the next token, and abandons
+// the current definition
+func (p *Parser) errorNext(msg string) {
+	pos, _, _ := p.sc.Peek()
+	p.fail(pos, msg)
+}
+
+// next scans the next token, keeping track of the depth of curly braces
+// and of the number of tokens
+func (p *Parser) next() (scanner.Position, scanner.Token, string) {
+	pos, tkn, lit := p.sc.Scan()
+	p.countToken(pos)
+	if p.KeepTrivia {
+		p.source = append(p.source, ast.Token{Token: tkn, Pos: pos, Lit: lit, Trivia: p.sc.Trivia()})
+	}
+	switch tkn {
+	case scanner.LCURLY:
+		p.depth++
+	case scanner.RCURLY:
+		p.depth--
+	}
+	return pos, tkn, lit
+}
+
+// location converts pos to a location as reported in errors
+func (p *Parser) location(pos scanner.Position) gqlerror.Location {
+	return gqlerror.Location{Line: pos.Line, Column: pos.UTF16Column}
+}
+
+// node records the position of the next token as the start of n, and
+// returns a func that records the end of the last consumed token as the end
+// of n. It is meant to be deferred
+//
+// When keeping trivia, the comments before the next token are attached to
+// n, unless an enclosing node starting at the same token already has them
+func (p *Parser) node(n *ast.Node) func() {
+	n.Start, _, _ = p.sc.Peek()
+	if p.KeepTrivia && n.Start.Offset != p.commented {
+		p.commented = n.Start.Offset
+		for _, t := range p.sc.PeekTrivia() {
+			if t.Token == scanner.COMMENT {
+				n.Comments = append(n.Comments, t.Lit)
+			}
+		}
+	}
+	return func() { n.End = p.sc.End() }
+}
+
+// keepTokens hands the tokens consumed when keeping trivia to doc, ending
+// them with EOF and the trivia at the end of the source, whose comments
+// become the trailing comments of doc. It is meant to be deferred by Parse
+func (p *Parser) keepTokens(doc *ast.Document) {
+	if !p.KeepTrivia {
+		return
+	}
+	if pos, tkn, _ := p.sc.Peek(); tkn == scanner.EOF {
+		trivia := p.sc.PeekTrivia()
+		p.source = append(p.source, ast.Token{Token: tkn, Pos: pos, Trivia: trivia})
+		for _, t := range trivia {
+			if t.Token == scanner.COMMENT {
+				doc.TrailingComments = append(doc.TrailingComments, t.Lit)
+			}
+		}
+	}
+	doc.Tokens = p.source
+}
+
+// nextKeyword returns the name that starts the next definition, skipping
+// over its description
+func (p *Parser) nextKeyword() string {
+	n := 0
+	if p.hasNextTkn(scanner.STRING) || p.hasNextTkn(scanner.BLOCKSTRING) {
+		n = 1
+	}
+	_, tkn, lit := p.sc.PeekN(n)
+	if tkn != scanner.NAME {
+		return ""
+	}
+	return lit
+}
+
 func (p *Parser) hasNext(token scanner.Token, literal string) bool {
 	_, tkn, lit := p.sc.Peek()
 	return tkn == token && lit == literal
@@ -19,35 +125,41 @@ func (p *Parser) hasNextTkn(token scanner.Token) bool {
 }
 func (p *Parser) consumeNameLiteral(literal string) {
 	if !p.hasNext(scanner.NAME, literal) {
-		p.errors = append(p.errors, errors.New("expected to find the name "+literal))
+		p.errorNext("expected to find the name " + literal)
 		return
 	}
 
-	p.sc.Scan()
+	p.next()
 }
 func (p *Parser) consumeName() string {
 	return p.consumeToken(scanner.NAME)
 }
 func (p *Parser) consumeToken(tkn scanner.Token) string {
 	if !p.hasNextTkn(tkn) {
-		p.errors = append(p.errors, errors.New("expected to find a different token"))
+		p.errorNext("expected to find a different token")
 		return ""
 	}
 
-	_, _, lit := p.sc.Scan()
+	_, _, lit := p.next()
 	return lit
 }
+
+// consumeString consumes a string or block string, returning its value
 func (p *Parser) consumeString() string {
-	_, tkn, lit := p.sc.Peek()
+	pos, tkn, lit := p.sc.Peek()
 	switch tkn {
 	case scanner.STRING:
-		p.sc.Scan()
-		return lit[1 : len(lit)-1]
+		p.next()
+		s, err := stringValue(lit[1 : len(lit)-1])
+		if err != nil {
+			p.errorAt(pos, err.Error())
+		}
+		return s
 	case scanner.BLOCKSTRING:
-		p.sc.Scan()
-		return lit[3 : len(lit)-3]
+		p.next()
+		return blockStringValue(lit[3 : len(lit)-3])
 	default:
-		p.errors = append(p.errors, errors.New("expected to find a string"))
+		p.errorNext("expected to find a string")
 		return ""
 	}
 }
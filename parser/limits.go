@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/scanner"
+)
+
+// Limits bound the documents a Parser accepts, so that untrusted input
+// cannot make it use unbounded memory, time or stack. A zero field means
+// no limit
+type Limits struct {
+	// MaxSize is the largest document accepted, in bytes
+	MaxSize int
+
+	// MaxTokens is the largest number of tokens in a document
+	MaxTokens int
+
+	// MaxDepth is how deeply selection sets, list and object values, and
+	// list types may be nested, counted together
+	MaxDepth int
+}
+
+// DefaultLimits are generous limits for documents sent by clients
+var DefaultLimits = Limits{
+	MaxSize:   1 << 20,
+	MaxTokens: 100000,
+	MaxDepth:  100,
+}
+
+// abort is panicked with to stop parsing the document altogether
+type abort struct{}
+
+// stop records an error pointing at pos, and stops parsing
+func (p *Parser) stop(pos scanner.Position, msg string) {
+	p.errorAt(pos, msg)
+	panic(abort{})
+}
+
+// recoverAbort recovers from stop. It is meant to be deferred by Parse
+func (p *Parser) recoverAbort() {
+	if r := recover(); r != nil {
+		if _, ok := r.(abort); !ok {
+			panic(r)
+		}
+	}
+}
+
+// checkSize reports whether the source fits in MaxSize
+func (p *Parser) checkSize() bool {
+	if p.MaxSize > 0 && p.size > p.MaxSize {
+		p.errors = append(p.errors, gqlerror.Errorf("document is larger than the limit of %d bytes", p.MaxSize))
+		return false
+	}
+	return true
+}
+
+// countToken stops parsing once more than MaxTokens tokens are consumed
+func (p *Parser) countToken(pos scanner.Position) {
+	p.tokens++
+	if p.MaxTokens > 0 && p.tokens > p.MaxTokens {
+		p.stop(pos, fmt.Sprintf("document has more than the limit of %d tokens", p.MaxTokens))
+	}
+}
+
+// nest enters a nested selection set, value or type, stopping parsing if
+// that goes deeper than MaxDepth. It returns a func that leaves it again,
+// which is meant to be deferred
+func (p *Parser) nest() func() {
+	p.nesting++
+	if p.MaxDepth > 0 && p.nesting > p.MaxDepth {
+		pos, _, _ := p.sc.Peek()
+		p.stop(pos, fmt.Sprintf("document is nested deeper than the limit of %d levels", p.MaxDepth))
+	}
+	return func() { p.nesting-- }
+}
@@ -1,16 +1,41 @@
 package parser
 
 import (
-	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/dianelooney/graphql/ast"
 	"github.com/dianelooney/graphql/scanner"
 )
 
 type Parser struct {
+	// Limits bound the documents Parse accepts
+	Limits
+
+	// KeepTrivia makes the parser keep the whitespace, commas and comments
+	// of the source: the tokens are recorded in Document.Tokens together
+	// with the trivia before them, and comments are attached to the nodes
+	// that follow them. It must be set before Init
+	KeepTrivia bool
+
 	sc     scanner.Scanner
 	errors []error
+	size   int
+
+	// reported holds the errors recorded so far, to skip duplicates
+	reported map[errorKey]bool
+
+	// depth counts the curly braces opened and not yet closed, nesting the
+	// selection sets, values and types being parsed, and tokens the tokens
+	// consumed so far
+	depth   int
+	nesting int
+	tokens  int
+
+	// source holds the tokens consumed so far when keeping trivia, and
+	// commented the offset of the token whose comments were last attached
+	source    []ast.Token
+	commented int
 }
 
 func (p *Parser) Errors() []error {
@@ -18,73 +43,195 @@ func (p *Parser) Errors() []error {
 }
 
 func (p *Parser) Init(src []byte) {
-	p.sc = scanner.Scanner{}
+	p.errors, p.reported = nil, nil
+	p.size = len(src)
+	p.depth, p.nesting, p.tokens = 0, 0, 0
+	p.source, p.commented = nil, -1
+	p.sc = scanner.Scanner{KeepTrivia: p.KeepTrivia}
 	p.sc.Init(src)
 }
 
-func (p *Parser) Parse() (doc ast.Document) {
+// Parse parses a document that may hold any kind of definition
+func (p *Parser) Parse() ast.Document {
+	return p.parse(anyDocument)
+}
+
+// ParseQuery parses an executable document, which may only hold operations
+// and fragments. Other definitions are reported as errors and left out
+func (p *Parser) ParseQuery() ast.Document {
+	return p.parse(executableDocument)
+}
+
+// ParseSchema parses a type system document, which may only hold schema,
+// type and directive definitions and extensions. Operations and fragments
+// are reported as errors and left out
+func (p *Parser) ParseSchema() ast.Document {
+	return p.parse(schemaDocument)
+}
+
+// documentKind restricts the definitions a document may hold
+type documentKind int
+
+const (
+	anyDocument documentKind = iota
+	executableDocument
+	schemaDocument
+)
+
+func (p *Parser) parse(kind documentKind) (doc ast.Document) {
+	defer p.node(&doc.Node)()
+	// the comments at the start belong to the first definition
+	doc.Comments, p.commented = nil, -1
 	doc.Types = make(map[string]ast.TypeDef)
 	doc.Directives = make(map[string]ast.DirectiveDef)
 	doc.Fragments = make(map[string]ast.FragmentDef)
+	doc.Operations = make(map[string]ast.Operation)
+	if !p.checkSize() {
+		return
+	}
 
-	for {
-		var desc *string
-		if p.hasNextTkn(scanner.EOF) {
-			break
+	defer p.keepTokens(&doc)
+	defer p.recoverAbort()
+	for !p.hasNextTkn(scanner.EOF) {
+		start, _, _ := p.sc.Peek()
+		p.parseDefinition(&doc, kind)
+		if pos, _, _ := p.sc.Peek(); pos == start {
+			// make sure a failed definition cannot stall the parser
+			p.next()
 		}
+	}
 
-		if p.hasNextName("schema") {
-			schema := p.parseSchema()
-			doc.Schema = &schema
-			continue
-		} else if p.hasNextName("query") ||
-			p.hasNextName("mutation") ||
-			p.hasNextName("subscription") {
-			op := p.parseOperationDef()
-			doc.Operations[*op.Name] = op
-			continue
-		} else if p.hasNextTkn(scanner.LCURLY) {
-			op := p.parseOperationDef()
-			doc.Operations[*op.Name] = op
-			continue
-		} else if p.hasNextName("fragment") {
-			frag := p.parseFragmentDef()
-			doc.Fragments[frag.Name] = frag
-			continue
-		}
+	return
+}
+
+// parseDefinition parses one top level definition into doc. After a syntax
+// error the definition is dropped, and the parser skips ahead to where the
+// next one seems to start. Definitions that a document of kind cannot hold
+// are dropped as well
+func (p *Parser) parseDefinition(doc *ast.Document, kind documentKind) {
+	defer p.recover()
 
-		desc = p.parseDescription()
-		if p.hasNextName("scalar") {
-			scalar := p.parseScalarTypeDefinition(desc)
-			doc.Types[scalar.Name] = ast.TypeDef{ScalarDef: &scalar}
-		} else if p.hasNextName("type") {
-			obj := p.parseObjectTypeDefinition(desc)
-			doc.Types[obj.Name] = ast.TypeDef{ObjectTypeDef: &obj}
-		} else if p.hasNextName("interface") {
-			intf := p.parseInterfaceTypeDef(desc)
-			doc.Types[intf.Name] = ast.TypeDef{InterfaceDef: &intf}
-		} else if p.hasNextName("union") {
-			union := p.parseUnionDef(desc)
-			doc.Types[union.Name] = ast.TypeDef{UnionDef: &union}
-		} else if p.hasNextName("enum") {
-			enum := p.parseEnumDef(desc)
-			doc.Types[enum.Name] = ast.TypeDef{EnumDef: &enum}
-		} else if p.hasNextName("input") {
-			input := p.parseInputDef(desc)
-			doc.Types[input.Name] = ast.TypeDef{InputDef: &input}
-		} else if p.hasNextName("directive") {
-			dir := p.parseDirectiveDef(desc)
-			doc.Directives[dir.Name] = dir
+	start, _, _ := p.sc.Peek()
+	var def ast.Definition
+	if p.hasNextName("schema") {
+		schema := p.parseSchema()
+		def.Schema = &schema
+	} else if p.hasNextName("query") ||
+		p.hasNextName("mutation") ||
+		p.hasNextName("subscription") ||
+		p.hasNextTkn(scanner.LCURLY) {
+		op := p.parseOperationDef()
+		def.Operation = &op
+	} else if p.hasNextName("fragment") {
+		frag := p.parseFragmentDef()
+		def.Fragment = &frag
+	} else if p.hasNextName("extend") {
+		if _, _, extended := p.sc.PeekN(1); extended == "schema" {
+			ext := p.parseSchemaExtension()
+			def.SchemaExtension = &ext
 		} else {
-			_, _, lit := p.sc.Scan()
-			p.errors = append(p.errors, errors.New("unknown: "+lit))
+			ext := p.parseTypeExtension()
+			def.TypeExtension = &ext
 		}
+	} else if keyword := p.nextKeyword(); keyword == "directive" {
+		// type system definitions may start with a description
+		dir := p.parseDirectiveDef()
+		def.Directive = &dir
+	} else {
+		t := p.parseTypeDef(keyword)
+		def.Type = &t
 	}
 
+	executable := def.Operation != nil || def.Fragment != nil
+	switch {
+	case kind == executableDocument && !executable:
+		p.errorAt(start, "an executable document can only contain operations and fragments, found "+describe(def))
+		return
+	case kind == schemaDocument && executable:
+		p.errorAt(start, "a schema document can only contain type system definitions, found "+describe(def))
+		return
+	}
+	addDefinition(doc, def)
+}
+
+// addDefinition adds def to doc.Definitions and to the field of doc that
+// indexes it
+func addDefinition(doc *ast.Document, def ast.Definition) {
+	switch {
+	case def.Schema != nil:
+		doc.Schema = def.Schema
+	case def.Operation != nil && def.Operation.Name == nil:
+		doc.Operation = def.Operation
+	case def.Operation != nil:
+		doc.Operations[*def.Operation.Name] = *def.Operation
+	case def.Fragment != nil:
+		doc.Fragments[def.Fragment.Name] = *def.Fragment
+	case def.SchemaExtension != nil:
+		doc.SchemaExtensions = append(doc.SchemaExtensions, *def.SchemaExtension)
+	case def.TypeExtension != nil:
+		doc.TypeExtensions = append(doc.TypeExtensions, *def.TypeExtension)
+	case def.Directive != nil:
+		doc.Directives[def.Directive.Name] = *def.Directive
+	case def.Type != nil:
+		doc.Types[def.Type.Name()] = *def.Type
+	}
+	doc.Definitions = append(doc.Definitions, def)
+}
+
+// describe names a definition in errors, like "type Query" or "fragment F"
+func describe(def ast.Definition) string {
+	switch {
+	case def.Schema != nil:
+		return "the schema definition"
+	case def.Operation != nil && def.Operation.Name == nil:
+		return "an anonymous " + def.Operation.OpType
+	case def.Operation != nil:
+		return def.Operation.OpType + " " + *def.Operation.Name
+	case def.Fragment != nil:
+		return "fragment " + def.Fragment.Name
+	case def.SchemaExtension != nil:
+		return "a schema extension"
+	case def.TypeExtension != nil:
+		return "extend " + def.TypeExtension.Kind() + " " + def.TypeExtension.Name()
+	case def.Directive != nil:
+		return "directive @" + def.Directive.Name
+	case def.Type != nil:
+		return def.Type.Kind() + " " + def.Type.Name()
+	}
+	return "an unknown definition"
+}
+
+// parseTypeDef parses the type definition started by keyword
+func (p *Parser) parseTypeDef(keyword string) (t ast.TypeDef) {
+	switch keyword {
+	case "scalar":
+		scalar := p.parseScalarTypeDefinition()
+		t.ScalarDef = &scalar
+	case "type":
+		obj := p.parseObjectTypeDefinition()
+		t.ObjectTypeDef = &obj
+	case "interface":
+		intf := p.parseInterfaceTypeDef()
+		t.InterfaceDef = &intf
+	case "union":
+		union := p.parseUnionDef()
+		t.UnionDef = &union
+	case "enum":
+		enum := p.parseEnumDef()
+		t.EnumDef = &enum
+	case "input":
+		input := p.parseInputDef()
+		t.InputDef = &input
+	default:
+		p.parseDescription()
+		pos, _, lit := p.next()
+		p.fail(pos, "unknown: "+lit)
+	}
 	return
 }
 
 func (p *Parser) parseOperationDef() (op ast.Operation) {
+	defer p.node(&op.Node)()
 	if p.hasNextName("query") ||
 		p.hasNextName("mutation") ||
 		p.hasNextName("subscription") {
@@ -117,8 +264,8 @@ func (p *Parser) parseSelection() (sel ast.Selection) {
 		field := p.parseField()
 		sel.Field = &field
 	} else {
-		p.consumeToken(scanner.ELLIPSIS)
-		if p.hasNextName("on") {
+		_, tkn, lit := p.sc.PeekN(1)
+		if lit == "on" || tkn != scanner.NAME {
 			frag := p.parseInlineFragment()
 			sel.InlineFragment = &frag
 		} else {
@@ -130,6 +277,7 @@ func (p *Parser) parseSelection() (sel ast.Selection) {
 	return
 }
 func (p *Parser) parseFragmentDef() (frag ast.FragmentDef) {
+	defer p.node(&frag.Node)()
 	p.consumeNameLiteral("fragment")
 	frag.Name = p.consumeName()
 	p.consumeNameLiteral("on")
@@ -140,6 +288,7 @@ func (p *Parser) parseFragmentDef() (frag ast.FragmentDef) {
 	return
 }
 func (p *Parser) parseField() (field ast.Field) {
+	defer p.node(&field.Node)()
 	n1 := p.consumeName()
 	if p.hasNextTkn(scanner.COLON) {
 		p.consumeToken(scanner.COLON)
@@ -160,26 +309,28 @@ func (p *Parser) parseField() (field ast.Field) {
 	return
 }
 func (p *Parser) parseInlineFragment() (frag ast.InlineFragment) {
-	// ... is already consumed, as we have to distinguish
-	// between InlineFragment and FragmentSpread
-	p.consumeNameLiteral("on")
-	inline := ast.InlineFragment{}
-	if p.hasNextTkn(scanner.NAME) {
+	defer p.node(&frag.Node)()
+	p.consumeToken(scanner.ELLIPSIS)
+	if p.hasNextName("on") {
+		p.consumeNameLiteral("on")
 		n := p.consumeName()
-		inline.Type = &n
+		frag.Type = &n
 	}
-	inline.Directives = p.parseDirectives()
-	inline.SelectionSet = p.parseSelectionSet()
+	frag.Directives = p.parseDirectives()
+	frag.SelectionSet = p.parseSelectionSet()
 
 	return
 }
 func (p *Parser) parseFragmentSpread() (frag ast.FragmentSpread) {
+	defer p.node(&frag.Node)()
+	p.consumeToken(scanner.ELLIPSIS)
 	frag.Name = p.consumeName()
 	frag.Directives = p.parseDirectives()
 
 	return
 }
 func (p *Parser) parseSelectionSet() (selections []ast.Selection) {
+	defer p.nest()()
 	p.consumeToken(scanner.LCURLY)
 	for {
 		if p.hasNextTkn(scanner.RCURLY) || p.hasNextTkn(scanner.EOF) {
@@ -194,9 +345,11 @@ func (p *Parser) parseSelectionSet() (selections []ast.Selection) {
 }
 
 func (p *Parser) parseVariableDef() (vari ast.VariableDef) {
+	defer p.node(&vari.Node)()
 	p.consumeToken(scanner.DOLLAR)
 	vari.Name = p.consumeName()
 	p.consumeToken(scanner.COLON)
+	vari.Type = p.parseType()
 	if p.hasNextTkn(scanner.EQL) {
 		p.consumeToken(scanner.EQL)
 		v := p.parseValue()
@@ -208,37 +361,85 @@ func (p *Parser) parseVariableDef() (vari ast.VariableDef) {
 }
 
 func (p *Parser) parseSchema() (schema ast.Schema) {
+	defer p.node(&schema.Node)()
+	p.consumeNameLiteral("schema")
+	schema.Directives = p.parseDirectives()
+	schema.RootOperationTypeDefs = p.parseRootOpTypeDefinitions()
+
+	return
+}
+func (p *Parser) parseSchemaExtension() (schema ast.Schema) {
+	defer p.node(&schema.Node)()
+	p.consumeNameLiteral("extend")
 	p.consumeNameLiteral("schema")
 	schema.Directives = p.parseDirectives()
+	if p.hasNextTkn(scanner.LCURLY) {
+		schema.RootOperationTypeDefs = p.parseRootOpTypeDefinitions()
+	}
+
+	return
+}
+func (p *Parser) parseRootOpTypeDefinitions() (defs []ast.RootOperationTypeDef) {
 	p.consumeToken(scanner.LCURLY)
 	for {
 		if p.hasNextTkn(scanner.RCURLY) || p.hasNextTkn(scanner.EOF) {
 			break
 		}
 
-		schema.RootOperationTypeDefs = append(schema.RootOperationTypeDefs, p.parseRootOpTypeDefinition())
+		defs = append(defs, p.parseRootOpTypeDefinition())
 	}
 	p.consumeToken(scanner.RCURLY)
 
 	return
 }
+func (p *Parser) parseTypeExtension() (ext ast.TypeExtension) {
+	defer p.node(&ext.Node)()
+	p.consumeNameLiteral("extend")
+	switch {
+	case p.hasNextName("scalar"):
+		scalar := p.parseScalarTypeDefinition()
+		ext.ScalarDef = &scalar
+	case p.hasNextName("type"):
+		obj := p.parseObjectTypeDefinition()
+		ext.ObjectTypeDef = &obj
+	case p.hasNextName("interface"):
+		intf := p.parseInterfaceTypeDef()
+		ext.InterfaceDef = &intf
+	case p.hasNextName("union"):
+		union := p.parseUnionDef()
+		ext.UnionDef = &union
+	case p.hasNextName("enum"):
+		enum := p.parseEnumDef()
+		ext.EnumDef = &enum
+	case p.hasNextName("input"):
+		input := p.parseInputDef()
+		ext.InputDef = &input
+	default:
+		p.errorNext("expected a schema or type to extend")
+	}
+
+	return
+}
 func (p *Parser) parseRootOpTypeDefinition() (def ast.RootOperationTypeDef) {
+	defer p.node(&def.Node)()
 	def.OpType = p.consumeName()
 	p.consumeToken(scanner.COLON)
 	def.NamedType = p.consumeName()
 
 	return
 }
-func (p *Parser) parseScalarTypeDefinition(desc *string) (scalar ast.ScalarDef) {
-	scalar.Description = desc
+func (p *Parser) parseScalarTypeDefinition() (scalar ast.ScalarDef) {
+	defer p.node(&scalar.Node)()
+	scalar.Description = p.parseDescription()
 	p.consumeNameLiteral("scalar")
 	scalar.Name = p.consumeName()
 	scalar.Directives = p.parseDirectives()
 
 	return
 }
-func (p *Parser) parseObjectTypeDefinition(desc *string) (obj ast.ObjectTypeDef) {
-	obj.Description = desc
+func (p *Parser) parseObjectTypeDefinition() (obj ast.ObjectTypeDef) {
+	defer p.node(&obj.Node)()
+	obj.Description = p.parseDescription()
 	p.consumeNameLiteral("type")
 	obj.Name = p.consumeName()
 	if p.hasNextName("implements") {
@@ -253,10 +454,14 @@ func (p *Parser) parseObjectTypeDefinition(desc *string) (obj ast.ObjectTypeDef)
 
 	return
 }
-func (p *Parser) parseInterfaceTypeDef(desc *string) (intf ast.InterfaceDef) {
-	intf.Description = desc
+func (p *Parser) parseInterfaceTypeDef() (intf ast.InterfaceDef) {
+	defer p.node(&intf.Node)()
+	intf.Description = p.parseDescription()
 	p.consumeNameLiteral("interface")
 	intf.Name = p.consumeName()
+	if p.hasNextName("implements") {
+		intf.ImplementsInterface = p.parseImplements()
+	}
 	intf.Directives = p.parseDirectives()
 
 	if !p.hasNextTkn(scanner.LCURLY) {
@@ -266,8 +471,9 @@ func (p *Parser) parseInterfaceTypeDef(desc *string) (intf ast.InterfaceDef) {
 
 	return
 }
-func (p *Parser) parseEnumDef(desc *string) (enum ast.EnumDef) {
-	enum.Description = desc
+func (p *Parser) parseEnumDef() (enum ast.EnumDef) {
+	defer p.node(&enum.Node)()
+	enum.Description = p.parseDescription()
 	p.consumeNameLiteral("enum")
 	enum.Name = p.consumeName()
 	enum.Directives = p.parseDirectives()
@@ -286,10 +492,12 @@ func (p *Parser) parseEnumDef(desc *string) (enum ast.EnumDef) {
 	return
 }
 func (p *Parser) parseEnumValueDef() (val ast.EnumValueDef) {
+	defer p.node(&val.Node)()
 	val.Description = p.parseDescription()
+	pos, _, _ := p.sc.Peek()
 	val.Name = p.consumeName()
 	if val.Name == "true" || val.Name == "false" || val.Name == "" {
-		p.errors = append(p.errors, errors.New("invalid enum value"))
+		p.errorAt(pos, "invalid enum value")
 		val.Name = ""
 	}
 	val.Directives = p.parseDirectives()
@@ -309,8 +517,9 @@ func (p *Parser) parseFieldDefs() (fields []ast.FieldDef) {
 
 	return
 }
-func (p *Parser) parseInputDef(desc *string) (input ast.InputDef) {
-	input.Description = desc
+func (p *Parser) parseInputDef() (input ast.InputDef) {
+	defer p.node(&input.Node)()
+	input.Description = p.parseDescription()
 	p.consumeNameLiteral("input")
 	input.Name = p.consumeName()
 	input.Directives = p.parseDirectives()
@@ -330,6 +539,7 @@ func (p *Parser) parseInputDef(desc *string) (input ast.InputDef) {
 	return
 }
 func (p *Parser) parseInputValueDef() (val ast.InputValueDef) {
+	defer p.node(&val.Node)()
 	val.Description = p.parseDescription()
 	val.Name = p.consumeName()
 	p.consumeToken(scanner.COLON)
@@ -344,6 +554,7 @@ func (p *Parser) parseInputValueDef() (val ast.InputValueDef) {
 	return
 }
 func (p *Parser) parseFieldDef() (field ast.FieldDef) {
+	defer p.node(&field.Node)()
 	field.Description = p.parseDescription()
 	field.Name = p.consumeName()
 	field.Arguments = p.parseArgumentsDefn()
@@ -353,22 +564,28 @@ func (p *Parser) parseFieldDef() (field ast.FieldDef) {
 
 	return
 }
-func (p *Parser) parseDirectiveDef(desc *string) (dir ast.DirectiveDef) {
-	dir.Description = desc
+func (p *Parser) parseDirectiveDef() (dir ast.DirectiveDef) {
+	defer p.node(&dir.Node)()
+	dir.Description = p.parseDescription()
 	p.consumeNameLiteral("directive")
 	p.consumeToken(scanner.AT)
 	dir.Name = p.consumeName()
 	dir.Arguments = p.parseArgumentsDefn()
+	if p.hasNextName("repeatable") {
+		p.next()
+		dir.Repeatable = true
+	}
 	p.consumeNameLiteral("on")
 	if p.hasNextTkn(scanner.BAR) {
 		p.consumeToken(scanner.BAR)
 	}
 	for {
+		pos, _, _ := p.sc.Peek()
 		location := p.consumeName()
 		_, isExec := ast.ExecutableDirectiveLocations[location]
 		_, isType := ast.TypeSystemDirectiveLocations[location]
 		if !isExec && !isType {
-			p.errors = append(p.errors, errors.New("unepected directive location"))
+			p.errorAt(pos, "unepected directive location")
 		} else {
 			dir.Locations = append(dir.Locations, location)
 		}
@@ -381,7 +598,9 @@ func (p *Parser) parseDirectiveDef(desc *string) (dir ast.DirectiveDef) {
 	return
 }
 func (p *Parser) parseType() (t ast.Type) {
+	defer p.node(&t.Node)()
 	if p.hasNextTkn(scanner.LSQUARE) {
+		defer p.nest()()
 		p.consumeToken(scanner.LSQUARE)
 		in := p.parseType()
 		t.ListType = &in
@@ -389,12 +608,16 @@ func (p *Parser) parseType() (t ast.Type) {
 	} else if p.hasNextTkn(scanner.NAME) {
 		n := p.consumeName()
 		t.Name = &n
+	} else {
+		p.errorNext("expected to find a type")
+		return
 	}
 
 	if p.hasNextTkn(scanner.BANG) {
-		var nullType ast.Type
-		nullType = t
-		t = ast.Type{NonNullType: &nullType}
+		nullType := t
+		nullType.End = p.sc.End()
+		p.consumeToken(scanner.BANG)
+		t = ast.Type{Node: ast.Node{Start: t.Start}, NonNullType: &nullType}
 	}
 
 	return
@@ -414,8 +637,9 @@ func (p *Parser) parseImplements() (implements []string) {
 	}
 	return
 }
-func (p *Parser) parseUnionDef(desc *string) (union ast.UnionDef) {
-	union.Description = desc
+func (p *Parser) parseUnionDef() (union ast.UnionDef) {
+	defer p.node(&union.Node)()
+	union.Description = p.parseDescription()
 	p.consumeNameLiteral("union")
 	union.Name = p.consumeName()
 	union.Directives = p.parseDirectives()
@@ -473,6 +697,7 @@ func (p *Parser) parseDirectives() (directives []ast.Directive) {
 	return
 }
 func (p *Parser) parseDirective() (directive ast.Directive) {
+	defer p.node(&directive.Node)()
 	p.consumeToken(scanner.AT)
 	directive.Name = p.consumeName()
 
@@ -482,59 +707,61 @@ func (p *Parser) parseDirective() (directive ast.Directive) {
 
 	return
 }
-func (p *Parser) parseArguments() (arguments map[string]ast.Value) {
-	arguments = make(map[string]ast.Value)
+func (p *Parser) parseArguments() (arguments ast.Arguments) {
+	arguments = make(ast.Arguments, 0)
 
 	if !p.hasNextTkn(scanner.LPAREN) {
-		p.errors = append(p.errors, errors.New("expected left paren to start argument list"))
+		p.errorNext("expected left paren to start argument list")
 		return
 	}
-	p.sc.Scan()
+	p.next()
 
 	for {
 		if p.hasNextTkn(scanner.RPAREN) || p.hasNextTkn(scanner.EOF) {
 			break
 		}
 
-		name, value := p.parseArgument()
-		arguments[name] = value
+		arguments = append(arguments, p.parseArgument())
 	}
 	p.consumeToken(scanner.RPAREN)
 	return
 }
-func (p *Parser) parseArgument() (name string, value ast.Value) {
-	name = p.consumeName()
+func (p *Parser) parseArgument() (arg ast.Argument) {
+	defer p.node(&arg.Node)()
+	arg.Name = p.consumeName()
 	p.consumeToken(scanner.COLON)
-	value = p.parseValue()
+	arg.Value = p.parseValue()
 
 	return
 }
-func (p *Parser) parseObjectField() (name string, value ast.Value) {
-	name = p.consumeName()
+func (p *Parser) parseObjectField() (field ast.Argument) {
+	defer p.node(&field.Node)()
+	field.Name = p.consumeName()
 	p.consumeToken(scanner.COLON)
-	value = p.parseValue()
+	field.Value = p.parseValue()
 
 	return
 }
 func (p *Parser) parseValue() (value ast.Value) {
+	defer p.node(&value.Node)()
 	_, tkn, _ := p.sc.Peek()
 	switch tkn {
 	case scanner.DOLLAR:
-		p.sc.Scan()
+		p.next()
 		name := p.consumeName()
 		value.Variable = &name
 	case scanner.INT:
-		_, _, lit := p.sc.Scan()
+		pos, _, lit := p.next()
 		val, err := strconv.Atoi(lit)
 		if err != nil {
-			p.errors = append(p.errors, errors.New("not an integer"))
+			p.errorAt(pos, "not an integer")
 		}
 		value.Int = &val
 	case scanner.FLOAT:
-		_, _, lit := p.sc.Scan()
+		pos, _, lit := p.next()
 		val, err := strconv.ParseFloat(lit, 64)
 		if err != nil {
-			p.errors = append(p.errors, errors.New("not a float"))
+			p.errorAt(pos, "not a float")
 		}
 		value.Float = &val
 	case scanner.STRING:
@@ -543,18 +770,19 @@ func (p *Parser) parseValue() (value ast.Value) {
 		str := p.consumeString()
 		value.String = &str
 	case scanner.BOOL:
-		_, _, lit := p.sc.Scan()
+		_, _, lit := p.next()
 		b, _ := strconv.ParseBool(lit)
 		value.Bool = &b
 	case scanner.NAME:
-		_, _, lit := p.sc.Scan()
+		_, _, lit := p.next()
 		if lit == "null" {
 			value.IsNull = true
 			break
 		}
 		value.Enum = &lit
 	case scanner.LSQUARE:
-		p.sc.Scan()
+		defer p.nest()()
+		p.next()
 		value.List = make([]ast.Value, 0)
 		for {
 			if p.hasNextTkn(scanner.RSQUARE) || p.hasNextTkn(scanner.EOF) {
@@ -565,20 +793,24 @@ func (p *Parser) parseValue() (value ast.Value) {
 		}
 		p.consumeToken(scanner.RSQUARE)
 	case scanner.LCURLY:
-		p.sc.Scan()
-		value.Object = make(map[string]ast.Value)
+		defer p.nest()()
+		p.next()
+		value.Object = make(ast.Arguments, 0)
 		for {
 			if p.hasNextTkn(scanner.RCURLY) || p.hasNextTkn(scanner.EOF) {
 				break
 			}
 
-			name, value := p.parseObjectField()
-			value.Object[name] = value
+			value.Object = append(value.Object, p.parseObjectField())
 		}
 		p.consumeToken(scanner.RCURLY)
 	default:
-		p.sc.Scan()
-		p.errors = append(p.errors, errors.New("unexpected token"))
+		pos, tkn, lit := p.next()
+		if tkn == scanner.ILLEGAL && strings.HasPrefix(lit, `"`) {
+			p.errorAt(pos, "invalid string "+lit)
+			break
+		}
+		p.fail(pos, "unexpected token")
 	}
 
 	return
@@ -1,8 +1,13 @@
 package parser_test
 
 import (
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
 	parser "github.com/dianelooney/graphql/parser"
 )
 
@@ -74,3 +79,466 @@ func TestSchemaParser(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestOperationParser(t *testing.T) {
+	src := `
+	{
+		a(obj: {x: 1, y: [true, null]})
+		... on T { b }
+		... @include(if: true) { c }
+		...Frag
+	}
+	query Named { d }
+	`
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Error(err)
+	}
+	if doc.Operation == nil || len(doc.Operation.SelectionSet) != 4 {
+		t.Fatalf("Expected the anonymous operation to have 4 selections, got %+v", doc.Operation)
+	}
+	sel := doc.Operation.SelectionSet
+	if obj := sel[0].Field.Arguments[0].Value.Object; len(obj) != 2 || obj[1].Name != "y" || len(obj[1].Value.List) != 2 {
+		t.Errorf("Expected the object argument to have 2 fields, got %+v", obj)
+	}
+	if sel[1].InlineFragment == nil || *sel[1].InlineFragment.Type != "T" {
+		t.Errorf("Expected an inline fragment on T, got %+v", sel[1])
+	}
+	if sel[2].InlineFragment == nil || sel[2].InlineFragment.Type != nil || len(sel[2].InlineFragment.Directives) != 1 {
+		t.Errorf("Expected an inline fragment without a type condition, got %+v", sel[2])
+	}
+	if sel[3].FragmentSpread == nil || sel[3].FragmentSpread.Name != "Frag" {
+		t.Errorf("Expected a spread of Frag, got %+v", sel[3])
+	}
+	if _, ok := doc.Operations["Named"]; !ok {
+		t.Errorf("Expected an operation named Named")
+	}
+}
+
+func TestErrorLocations(t *testing.T) {
+	src := "{\n  a\n  b(x: )\n}"
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	p.Parse()
+	errs := p.Errors()
+	if len(errs) == 0 {
+		t.Fatalf("Expected an error parsing %q", src)
+	}
+	err, ok := errs[0].(*gqlerror.Error)
+	if !ok {
+		t.Fatalf("Expected a *gqlerror.Error, got %T", errs[0])
+	}
+	if len(err.Locations) != 1 || err.Locations[0] != (gqlerror.Location{Line: 3, Column: 8}) {
+		t.Errorf("Expected the error to be at 3:8, got %v", err.Locations)
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	src := "{\n  a: b(x: [1])\n  ... on T { c }\n}\n\"desc\" type T { f: [Int]! }"
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+
+	expect := func(what string, n ast.Node, start, end int) {
+		if n.Start.Offset != start || n.End.Offset != end {
+			t.Errorf("Expected %s to span %d-%d, got %d-%d (%q)", what, start, end, n.Start.Offset, n.End.Offset, src[n.Start.Offset:n.End.Offset])
+		}
+	}
+	sel := doc.Operation.SelectionSet
+	expect("the operation", doc.Operation.Node, 0, 35)
+	expect("the field", sel[0].Field.Node, 4, 16)
+	expect("the argument", sel[0].Field.Arguments[0].Node, 9, 15)
+	expect("the argument value", sel[0].Field.Arguments[0].Value.Node, 12, 15)
+	expect("the inline fragment", sel[1].InlineFragment.Node, 19, 33)
+	obj := doc.Types["T"].ObjectTypeDef
+	expect("the type definition", obj.Node, 36, 63)
+	expect("the field type", obj.Fields[0].Type.Node, 55, 61)
+	expect("the nullable field type", obj.Fields[0].Type.NonNullType.Node, 55, 60)
+
+	if f := sel[1].InlineFragment.SelectionSet[0].Field; f.Start.Line != 3 || f.Start.Column != 14 {
+		t.Errorf("Expected c to start at 3:14, got %d:%d", f.Start.Line, f.Start.Column)
+	}
+}
+
+func TestParseExtensions(t *testing.T) {
+	src := `
+	extend schema @a { mutation: M }
+	extend schema @b
+	extend scalar S @c
+	extend type T implements I @d { f: Int }
+	extend interface I implements J { g: Int }
+	extend union U = A | B
+	extend enum E { X }
+	extend input In { y: Int }
+	`
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+
+	if len(doc.SchemaExtensions) != 2 || len(doc.SchemaExtensions[0].RootOperationTypeDefs) != 1 || len(doc.SchemaExtensions[1].Directives) != 1 {
+		t.Errorf("Expected two schema extensions, got %+v", doc.SchemaExtensions)
+	}
+	kinds := []string{"scalar", "type", "interface", "union", "enum", "input"}
+	if len(doc.TypeExtensions) != len(kinds) {
+		t.Fatalf("Expected %d type extensions, got %d", len(kinds), len(doc.TypeExtensions))
+	}
+	for i, ext := range doc.TypeExtensions {
+		if ext.Kind() != kinds[i] {
+			t.Errorf("Expected extension %d to extend a %s, got %s", i, kinds[i], ext.Kind())
+		}
+	}
+	if obj := doc.TypeExtensions[1].ObjectTypeDef; obj.Name != "T" || len(obj.ImplementsInterface) != 1 || len(obj.Fields) != 1 {
+		t.Errorf("Expected the type extension to add an interface and a field, got %+v", obj)
+	}
+	if intf := doc.TypeExtensions[2].InterfaceDef; len(intf.ImplementsInterface) != 1 || intf.ImplementsInterface[0] != "J" {
+		t.Errorf("Expected the interface extension to add an interface, got %+v", intf)
+	}
+	if len(doc.Types) != 0 {
+		t.Errorf("Expected extensions not to define types, got %v", doc.Types)
+	}
+}
+
+func TestParseVariableDefinitions(t *testing.T) {
+	src := `query Q($id: ID!, $list: [[Int!]] = [[1]] @dir, $f: Float) { f }`
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+
+	vars := doc.Operations["Q"].Variables
+	if len(vars) != 3 {
+		t.Fatalf("Expected 3 variable definitions, got %d", len(vars))
+	}
+	if vars[0].Type.NonNullType == nil || *vars[0].Type.NonNullType.Name != "ID" {
+		t.Errorf("Expected $id to be of type ID!, got %+v", vars[0].Type)
+	}
+	if l := vars[1].Type.ListType; l == nil || l.ListType == nil || l.ListType.NonNullType == nil || vars[1].DefaultValue == nil || len(vars[1].Directives) != 1 {
+		t.Errorf("Expected $list to be of type [[Int!]] with a default and a directive, got %+v", vars[1])
+	}
+
+	p.Init([]byte(`query ($id: = 1) { f }`))
+	p.Parse()
+	if len(p.Errors()) == 0 {
+		t.Errorf("Expected an error for a variable without a type")
+	}
+}
+
+func TestParseRepeatableDirective(t *testing.T) {
+	src := `directive @tag(name: String) repeatable on FIELD_DEFINITION | OBJECT directive @once on FIELD`
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+	if d := doc.Directives["tag"]; !d.Repeatable || len(d.Locations) != 2 {
+		t.Errorf("Expected @tag to be repeatable on two locations, got %+v", d)
+	}
+	if doc.Directives["once"].Repeatable {
+		t.Errorf("Expected @once not to be repeatable")
+	}
+}
+
+func TestStringValues(t *testing.T) {
+	tests := map[string]string{
+		`"plain"`:                     "plain",
+		`"esc\"aped\\ \/ \b\f\n\r\t"`: "esc\"aped\\ / \b\f\n\r\t",
+		`"café é"`:                    "café é",
+		`"\u{1F600} \u{e9}"`:          "😀 é",
+		`"😀"`:                         "😀",
+		`"""raw \n \""" "quoted" """`: `raw \n """ "quoted" `,
+		"\"\"\"\n\n    Hello,\n      World!\n\n    Yours,\n      GraphQL.\n  \n\"\"\"": "Hello,\n  World!\n\nYours,\n  GraphQL.",
+		"\"\"\"  first\r\n    second\r  third\"\"\"":                                   "  first\n  second\nthird",
+	}
+	for src, expected := range tests {
+		p := parser.Parser{}
+		p.Init([]byte(`{ f(s: ` + src + `) }`))
+		doc := p.Parse()
+		for _, err := range p.Errors() {
+			t.Fatalf("Error parsing %s: %v", src, err)
+		}
+		v, _ := doc.Operation.SelectionSet[0].Field.Arguments.Get("s")
+		if v.String == nil || *v.String != expected {
+			t.Errorf("Expected %s to have the value %q, got %q", src, expected, *v.String)
+		}
+	}
+
+	for _, src := range []string{`"\uD83D"`, `"\uDE00"`, `"\u{110000}"`, `"\u{D800}"`, `"\q"`, `"\u12"`} {
+		p := parser.Parser{}
+		p.Init([]byte(`{ f(s: ` + src + `) }`))
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("Expected an error parsing %s", src)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	src := `
+query A { a(x: ) b }
+query B { b }
+type T {
+  type: String
+  f(: Int
+}
+type U { u: Int }
+fragment F on T {
+{ shorthand }
+`
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+
+	var locs []gqlerror.Location
+	for _, err := range p.Errors() {
+		locs = append(locs, err.(*gqlerror.Error).Locations...)
+	}
+	expected := []gqlerror.Location{{Line: 2, Column: 16}, {Line: 6, Column: 5}, {Line: 10, Column: 1}}
+	if !reflect.DeepEqual(locs, expected) {
+		t.Errorf("Expected errors at %v, got %v", expected, p.Errors())
+	}
+
+	if _, ok := doc.Operations["B"]; !ok {
+		t.Errorf("Expected operation B to be parsed after the error in A")
+	}
+	if _, ok := doc.Types["U"]; !ok {
+		t.Errorf("Expected type U to be parsed after the error in T")
+	}
+	if doc.Operation == nil || doc.Operation.SelectionSet[0].Field.Name != "shorthand" {
+		t.Errorf("Expected the shorthand query to be parsed after the unclosed fragment")
+	}
+	if _, ok := doc.Operations["A"]; ok {
+		t.Errorf("Expected operation A to be dropped")
+	}
+
+	p.Init([]byte(strings.Repeat("{ a( }\n", 2*parser.MaxErrors)))
+	p.Parse()
+	if n := len(p.Errors()); n != parser.MaxErrors+1 {
+		t.Errorf("Expected errors to be capped at %d, got %d", parser.MaxErrors+1, n)
+	}
+
+	// errors within a single definition are capped too
+	p.Init([]byte(`{ a(x: [` + strings.Repeat(`"\q" `, 30000) + `]) }`))
+	p.Parse()
+	if n := len(p.Errors()); n != parser.MaxErrors+1 {
+		t.Errorf("Expected errors within a definition to be capped at %d, got %d", parser.MaxErrors+1, n)
+	}
+}
+
+func TestDefinitionOrder(t *testing.T) {
+	src := `
+type Q { a: Int }
+extend type Q { b: Int }
+fragment F on Q { a }
+query Z { a }
+query A { f(z: 1, a: {y: 2, x: 3}, z: 4) }
+type Q { c: Int }
+`
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+
+	if len(doc.Definitions) != 6 {
+		t.Fatalf("Expected 6 definitions, got %d", len(doc.Definitions))
+	}
+	defs := doc.Definitions
+	if defs[0].Type == nil || defs[1].TypeExtension == nil || defs[2].Fragment == nil || *defs[3].Operation.Name != "Z" || *defs[4].Operation.Name != "A" || defs[5].Type == nil {
+		t.Errorf("Expected the definitions in source order, got %+v", defs)
+	}
+	if doc.Types["Q"].ObjectTypeDef.Fields[0].Name != "c" {
+		t.Errorf("Expected the last definition of Q to be kept by name")
+	}
+
+	args := defs[4].Operation.SelectionSet[0].Field.Arguments
+	var names []string
+	for _, arg := range args {
+		names = append(names, arg.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"z", "a", "z"}) {
+		t.Errorf("Expected the arguments in source order with duplicates, got %v", names)
+	}
+	if obj := args[1].Value.Object; obj[0].Name != "y" || obj[1].Name != "x" {
+		t.Errorf("Expected the object fields in source order, got %+v", obj)
+	}
+	if v, _ := args.Get("z"); *v.Int != 4 {
+		t.Errorf("Expected Get to return the last z, got %d", *v.Int)
+	}
+
+	doc.MergeExtensions()
+	if len(doc.Definitions) != 5 || defs[1].TypeExtension == nil {
+		t.Fatalf("Expected the extension to be dropped from a copy of the definitions, got %d", len(doc.Definitions))
+	}
+	if first, last := doc.Definitions[0].Type.ObjectTypeDef, doc.Definitions[4].Type.ObjectTypeDef; len(first.Fields) != 1 || len(last.Fields) != 2 {
+		t.Errorf("Expected only the definition of Q kept by name to be extended, got %+v and %+v", first.Fields, last.Fields)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 500; i++ {
+		sb.WriteString(`"A type" type Type` + strconv.Itoa(i) + ` implements Node @key(fields: "id") {
+  id: ID!
+  name(first: Int = 10, after: String): [String!]! @deprecated(reason: "no")
+}
+`)
+	}
+	sb.WriteString("query Q($id: ID!) {\n")
+	for i := 0; i < 500; i++ {
+		sb.WriteString(`  a` + strconv.Itoa(i) + `: node(id: $id, n: [1, 2.5], o: {a: true, b: ENUM}) { ... on Type { id name } ...F }` + "\n")
+	}
+	sb.WriteString("}\n")
+	src := []byte(sb.String())
+
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := parser.Parser{}
+		p.Init(src)
+		p.Parse()
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		limits   parser.Limits
+		src      string
+		expected string
+	}{
+		{parser.Limits{MaxSize: 10}, `{ a b c d e }`, "document is larger than the limit of 10 bytes"},
+		{parser.Limits{MaxTokens: 5}, `{ a b c d e }`, "document has more than the limit of 5 tokens"},
+		{parser.Limits{MaxDepth: 3}, `{ a { b { c { d } } } }`, "document is nested deeper than the limit of 3 levels"},
+		{parser.Limits{MaxDepth: 3}, `{ a(x: [[[1]]]) }`, "document is nested deeper than the limit of 3 levels"},
+		{parser.Limits{MaxDepth: 3}, `{ a(x: {y: {z: {}}}) }`, "document is nested deeper than the limit of 3 levels"},
+		{parser.Limits{MaxDepth: 3}, `query ($x: [[[[Int]]]]) { a }`, "document is nested deeper than the limit of 3 levels"},
+		{parser.Limits{MaxDepth: 3}, strings.Repeat("{ a( }\n", 3) + `{ a { b { c { d } } } }`, "document is nested deeper than the limit of 3 levels"},
+	}
+	for _, test := range tests {
+		p := parser.Parser{Limits: test.limits}
+		p.Init([]byte(test.src))
+		p.Parse()
+		errs := p.Errors()
+		if len(errs) == 0 || errs[len(errs)-1].(*gqlerror.Error).Message != test.expected {
+			t.Errorf("Expected parsing %s with %+v to end with the error %q, got %v", test.src, test.limits, test.expected, errs)
+		}
+	}
+
+	p := parser.Parser{Limits: parser.Limits{MaxSize: 30, MaxTokens: 13, MaxDepth: 3}}
+	p.Init([]byte(`{ a { b(x: [1]) } }`))
+	p.Parse()
+	for _, err := range p.Errors() {
+		t.Errorf("Expected a document within the limits to parse, got %v", err)
+	}
+
+	// without limits, deep nesting is only bounded by memory
+	p = parser.Parser{}
+	p.Init([]byte(`{ a(x: ` + strings.Repeat("[", 10000) + strings.Repeat("]", 10000) + `) }`))
+	p.Parse()
+	for _, err := range p.Errors() {
+		t.Errorf("Expected deep nesting to parse without limits, got %v", err)
+	}
+}
+
+func TestParseDocumentKinds(t *testing.T) {
+	src := `
+query Q { a }
+"desc" type T { f: Int }
+fragment F on T { f }
+extend type T { g: Int }
+directive @d on FIELD
+{ b }
+schema { query: T }
+`
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.ParseQuery()
+	var messages []string
+	for _, err := range p.Errors() {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"3:1: an executable document can only contain operations and fragments, found type T",
+		"5:1: an executable document can only contain operations and fragments, found extend type T",
+		"6:1: an executable document can only contain operations and fragments, found directive @d",
+		"8:1: an executable document can only contain operations and fragments, found the schema definition",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Expected ParseQuery to return the errors\n%v\ngot\n%v", expected, messages)
+	}
+	if len(doc.Definitions) != 3 || len(doc.Types) != 0 || doc.Schema != nil || doc.Operation == nil || len(doc.Fragments) != 1 {
+		t.Errorf("Expected ParseQuery to keep only the operations and fragments, got %+v", doc.Definitions)
+	}
+
+	p.Init([]byte(src))
+	doc = p.ParseSchema()
+	messages = nil
+	for _, err := range p.Errors() {
+		messages = append(messages, err.Error())
+	}
+	expected = []string{
+		"2:1: a schema document can only contain type system definitions, found query Q",
+		"4:1: a schema document can only contain type system definitions, found fragment F",
+		"7:1: a schema document can only contain type system definitions, found an anonymous query",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Expected ParseSchema to return the errors\n%v\ngot\n%v", expected, messages)
+	}
+	if len(doc.Definitions) != 4 || len(doc.Operations) != 0 || doc.Operation != nil || len(doc.Fragments) != 0 {
+		t.Errorf("Expected ParseSchema to keep only the type system definitions, got %+v", doc.Definitions)
+	}
+
+	p.Init([]byte(src))
+	p.Parse()
+	for _, err := range p.Errors() {
+		t.Errorf("Expected Parse to accept every definition, got %v", err)
+	}
+}
+
+func TestKeepTrivia(t *testing.T) {
+	src := "\ufeff# the query type\r\n\"desc\" type Query {\n  # a field\n  a(x: Int, y: Int): Int,, # after a\n}\n\n{ a }\n# the end\n"
+	p := parser.Parser{KeepTrivia: true}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing: %v", err)
+	}
+
+	if out := doc.Source(); out != src {
+		t.Errorf("Expected Source to return\n%q\ngot\n%q", src, out)
+	}
+	def := doc.Types["Query"].ObjectTypeDef
+	if expected := []string{"# the query type"}; !reflect.DeepEqual(def.Comments, expected) {
+		t.Errorf("Expected the type to have comments %q, got %q", expected, def.Comments)
+	}
+	if doc.Comments != nil {
+		t.Errorf("Expected the comments to be attached to the type, not the document")
+	}
+	if expected := []string{"# a field"}; !reflect.DeepEqual(def.Fields[0].Comments, expected) {
+		t.Errorf("Expected the field to have comments %q, got %q", expected, def.Fields[0].Comments)
+	}
+	if sel := doc.Operation.SelectionSet[0].Field; sel.Comments != nil {
+		t.Errorf("Expected the selection to have no comments, got %q", sel.Comments)
+	}
+	if expected := []string{"# the end"}; !reflect.DeepEqual(doc.TrailingComments, expected) {
+		t.Errorf("Expected trailing comments %q, got %q", expected, doc.TrailingComments)
+	}
+
+	p = parser.Parser{}
+	p.Init([]byte(src))
+	doc = p.Parse()
+	if doc.Tokens != nil || doc.Types["Query"].ObjectTypeDef.Comments != nil {
+		t.Errorf("Expected no tokens or comments without KeepTrivia")
+	}
+}
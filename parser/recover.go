@@ -0,0 +1,66 @@
+package parser
+
+import "github.com/dianelooney/graphql/scanner"
+
+// MaxErrors is the number of errors after which Parse gives up
+const MaxErrors = 10
+
+// bailout is panicked with to abandon the definition being parsed
+type bailout struct{}
+
+// definitionKeywords are the names that can start a top level definition
+var definitionKeywords = map[string]bool{
+	"query":        true,
+	"mutation":     true,
+	"subscription": true,
+	"fragment":     true,
+	"schema":       true,
+	"extend":       true,
+	"scalar":       true,
+	"type":         true,
+	"interface":    true,
+	"union":        true,
+	"enum":         true,
+	"input":        true,
+	"directive":    true,
+}
+
+// fail records a syntax error pointing at pos, and abandons the current
+// definition. Parse recovers from it and carries on with the next one
+func (p *Parser) fail(pos scanner.Position, msg string) {
+	p.errorAt(pos, msg)
+	panic(bailout{})
+}
+
+// recover recovers from a failed definition, skipping the tokens that are
+// left of it. It is meant to be deferred
+func (p *Parser) recover() {
+	if r := recover(); r != nil {
+		if _, ok := r.(bailout); !ok {
+			panic(r)
+		}
+		p.synchronize()
+	}
+}
+
+// synchronize skips tokens up to the start of the next definition: a
+// definition keyword or description outside of any curly braces, or a
+// definition keyword or shorthand query at the start of a line
+//
+// Definitions usually start at the start of a line, which lets the parser
+// recover from unbalanced braces as well
+func (p *Parser) synchronize() {
+	for {
+		pos, tkn, lit := p.sc.Peek()
+		switch {
+		case tkn == scanner.EOF:
+			p.depth = 0
+			return
+		case pos.Column == 1 && (tkn == scanner.LCURLY || tkn == scanner.NAME && definitionKeywords[lit]),
+			p.depth <= 0 && definitionKeywords[p.nextKeyword()]:
+			p.depth = 0
+			return
+		}
+		p.next()
+	}
+}
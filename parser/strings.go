@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var errInvalidUnicode = errors.New("invalid unicode escape sequence")
+
+// stringValue decodes the escape sequences of a string literal, without its quotes
+func stringValue(raw string) (string, error) {
+	if !strings.Contains(raw, `\`) {
+		return raw, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' || i+1 >= len(raw) {
+			b.WriteByte(raw[i])
+			continue
+		}
+
+		i++
+		switch raw[i] {
+		case '"', '\\', '/':
+			b.WriteByte(raw[i])
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			r, size, err := unicodeEscape(raw[i+1:])
+			if err != nil {
+				return "", err
+			}
+			i += size
+
+			if r >= 0xD800 && r <= 0xDBFF {
+				// a leading surrogate must be followed by an escaped trailing one
+				if !strings.HasPrefix(raw[i+1:], `\u`) {
+					return "", errInvalidUnicode
+				}
+				trail, trailSize, err := unicodeEscape(raw[i+3:])
+				if err != nil || trail < 0xDC00 || trail > 0xDFFF {
+					return "", errInvalidUnicode
+				}
+				r = (r-0xD800)<<10 + (trail - 0xDC00) + 0x10000
+				i += 2 + trailSize
+			} else if r >= 0xDC00 && r <= 0xDFFF || !utf8.ValidRune(r) {
+				return "", errInvalidUnicode
+			}
+			b.WriteRune(r)
+		default:
+			return "", errors.New("invalid escape sequence \\" + string(raw[i]))
+		}
+	}
+	return b.String(), nil
+}
+
+// unicodeEscape decodes the code point of a \u escape, given what follows
+// the u, returning the number of bytes it used
+func unicodeEscape(s string) (rune, int, error) {
+	hex, size := "", 0
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 2 {
+			return 0, 0, errInvalidUnicode
+		}
+		hex, size = s[1:end], end+1
+	} else {
+		if len(s) < 4 {
+			return 0, 0, errInvalidUnicode
+		}
+		hex, size = s[:4], 4
+	}
+
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || n > utf8.MaxRune {
+		return 0, 0, errInvalidUnicode
+	}
+	return rune(n), size, nil
+}
+
+// blockStringValue implements the spec's BlockStringValue, given the raw
+// contents of a block string without its quotes
+//
+// Escaped triple quotes are unescaped, the common indentation of all lines
+// but the first is removed, and leading and trailing blank lines are dropped
+func blockStringValue(raw string) string {
+	raw = strings.Replace(raw, `\"""`, `"""`, -1)
+	raw = strings.Replace(raw, "\r\n", "\n", -1)
+	lines := strings.Split(strings.Replace(raw, "\r", "\n", -1), "\n")
+
+	commonIndent := -1
+	for _, line := range lines[1:] {
+		indent := leadingWhitespace(line)
+		if indent < len(line) && (commonIndent == -1 || indent < commonIndent) {
+			commonIndent = indent
+		}
+	}
+	if commonIndent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) < commonIndent {
+				lines[i] = ""
+			} else {
+				lines[i] = lines[i][commonIndent:]
+			}
+		}
+	}
+
+	for len(lines) > 0 && leadingWhitespace(lines[0]) == len(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && leadingWhitespace(lines[len(lines)-1]) == len(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// leadingWhitespace counts the spaces and tabs at the start of line
+func leadingWhitespace(line string) int {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+	return i
+}
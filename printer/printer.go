@@ -0,0 +1,686 @@
+// Package printer turns AST nodes back into GraphQL source
+//
+// Definitions, arguments and object fields are printed in the order they
+// appear in the source, selection sets and field definitions are indented
+// by two spaces, and descriptions are printed as block strings
+//
+// Comments kept by the parser are printed on lines of their own before
+// definitions, selections, field and enum value definitions, input field
+// definitions and root operation types. Comments on the nodes printed within
+// a line, such as arguments and values, are left out
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dianelooney/graphql/ast"
+)
+
+// Fprint writes the source of node to w
+//
+// node is an ast.Document, ast.Definition, ast.Operation, ast.FragmentDef, ast.Selection,
+// []ast.Selection, ast.Field, ast.Value, ast.Type, ast.Directive,
+// ast.TypeDef, ast.TypeExtension, ast.DirectiveDef, ast.Schema or one of the
+// type definitions, or a pointer to any of these
+func Fprint(w io.Writer, node interface{}) error {
+	var p printer
+	if err := p.node(node); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, p.String())
+	return err
+}
+
+// Sprint returns the source of node
+//
+// It panics when node is not one of the types accepted by Fprint
+func Sprint(node interface{}) string {
+	var p printer
+	if err := p.node(node); err != nil {
+		panic(err)
+	}
+	return p.String()
+}
+
+type printer struct {
+	strings.Builder
+	indent int
+}
+
+func (p *printer) node(node interface{}) error {
+	switch n := node.(type) {
+	case ast.Document:
+		p.document(n)
+	case *ast.Document:
+		p.document(*n)
+	case ast.Definition:
+		p.definition(n)
+	case *ast.Definition:
+		p.definition(*n)
+	case ast.Operation:
+		p.operation(n)
+	case *ast.Operation:
+		p.operation(*n)
+	case ast.FragmentDef:
+		p.fragmentDef(n)
+	case *ast.FragmentDef:
+		p.fragmentDef(*n)
+	case ast.Selection:
+		p.selection(n)
+	case *ast.Selection:
+		p.selection(*n)
+	case []ast.Selection:
+		p.selectionSet(n)
+	case ast.Field:
+		p.field(n)
+	case *ast.Field:
+		p.field(*n)
+	case ast.Value:
+		p.value(n)
+	case *ast.Value:
+		p.value(*n)
+	case ast.Type:
+		p.typ(n)
+	case *ast.Type:
+		p.typ(*n)
+	case ast.Directive:
+		p.directive(n)
+	case *ast.Directive:
+		p.directive(*n)
+	case ast.Schema:
+		p.schema(n)
+	case *ast.Schema:
+		p.schema(*n)
+	case ast.TypeDef:
+		p.typeDef(n)
+	case *ast.TypeDef:
+		p.typeDef(*n)
+	case ast.TypeExtension:
+		p.typeExtension(n)
+	case *ast.TypeExtension:
+		p.typeExtension(*n)
+	case ast.DirectiveDef:
+		p.directiveDef(n)
+	case *ast.DirectiveDef:
+		p.directiveDef(*n)
+	case *ast.ScalarDef:
+		p.typeDef(ast.TypeDef{ScalarDef: n})
+	case *ast.ObjectTypeDef:
+		p.typeDef(ast.TypeDef{ObjectTypeDef: n})
+	case *ast.InterfaceDef:
+		p.typeDef(ast.TypeDef{InterfaceDef: n})
+	case *ast.UnionDef:
+		p.typeDef(ast.TypeDef{UnionDef: n})
+	case *ast.EnumDef:
+		p.typeDef(ast.TypeDef{EnumDef: n})
+	case *ast.InputDef:
+		p.typeDef(ast.TypeDef{InputDef: n})
+	case ast.ScalarDef:
+		p.typeDef(ast.TypeDef{ScalarDef: &n})
+	case ast.ObjectTypeDef:
+		p.typeDef(ast.TypeDef{ObjectTypeDef: &n})
+	case ast.InterfaceDef:
+		p.typeDef(ast.TypeDef{InterfaceDef: &n})
+	case ast.UnionDef:
+		p.typeDef(ast.TypeDef{UnionDef: &n})
+	case ast.EnumDef:
+		p.typeDef(ast.TypeDef{EnumDef: &n})
+	case ast.InputDef:
+		p.typeDef(ast.TypeDef{InputDef: &n})
+	default:
+		return fmt.Errorf("printer: unsupported node type %T", node)
+	}
+	return nil
+}
+
+func (p *printer) newline() {
+	p.WriteByte('\n')
+	for i := 0; i < p.indent; i++ {
+		p.WriteString("  ")
+	}
+}
+
+// document prints the definitions of doc, separated by blank lines
+//
+// They are printed in the order of doc.Definitions. Documents built without
+// it get the schema definition, directive definitions, type definitions,
+// extensions, operations and fragments, each sorted by name
+func (p *printer) document(doc ast.Document) {
+	first := true
+	next := func() {
+		if !first {
+			p.WriteString("\n\n")
+		}
+		first = false
+	}
+
+	if len(doc.Definitions) > 0 {
+		for _, def := range doc.Definitions {
+			next()
+			p.definition(def)
+		}
+		p.trailingComments(doc.TrailingComments)
+		p.WriteByte('\n')
+		return
+	}
+
+	if doc.Schema != nil {
+		next()
+		p.schema(*doc.Schema)
+	}
+	for _, name := range ast.SortedNames(doc.Directives) {
+		next()
+		p.directiveDef(doc.Directives[name])
+	}
+	for _, name := range ast.SortedNames(doc.Types) {
+		next()
+		p.typeDef(doc.Types[name])
+	}
+	for _, ext := range doc.SchemaExtensions {
+		next()
+		p.schemaExtension(ext)
+	}
+	for _, ext := range doc.TypeExtensions {
+		next()
+		p.typeExtension(ext)
+	}
+	if doc.Operation != nil {
+		next()
+		p.operation(*doc.Operation)
+	}
+	for _, name := range ast.SortedNames(doc.Operations) {
+		next()
+		p.operation(doc.Operations[name])
+	}
+	for _, name := range ast.SortedNames(doc.Fragments) {
+		next()
+		p.fragmentDef(doc.Fragments[name])
+	}
+	if !first {
+		p.trailingComments(doc.TrailingComments)
+		p.WriteByte('\n')
+	}
+}
+
+// definition prints the one definition set in def
+func (p *printer) definition(def ast.Definition) {
+	switch {
+	case def.Operation != nil:
+		p.operation(*def.Operation)
+	case def.Fragment != nil:
+		p.fragmentDef(*def.Fragment)
+	case def.Schema != nil:
+		p.schema(*def.Schema)
+	case def.Type != nil:
+		p.typeDef(*def.Type)
+	case def.Directive != nil:
+		p.directiveDef(*def.Directive)
+	case def.SchemaExtension != nil:
+		p.schemaExtension(*def.SchemaExtension)
+	case def.TypeExtension != nil:
+		p.typeExtension(*def.TypeExtension)
+	}
+}
+
+// operation prints op, using the query shorthand when it has no name,
+// variables or directives
+func (p *printer) operation(op ast.Operation) {
+	p.comments(op.Comments)
+	opType := op.OpType
+	if opType == "" {
+		opType = "query"
+	}
+	if opType == "query" && op.Name == nil && len(op.Variables) == 0 && len(op.Directives) == 0 {
+		p.selectionSet(op.SelectionSet)
+		return
+	}
+
+	p.WriteString(opType)
+	if op.Name != nil {
+		p.WriteString(" " + *op.Name)
+	}
+	if len(op.Variables) > 0 {
+		p.WriteByte('(')
+		for i, v := range op.Variables {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			p.variableDef(v)
+		}
+		p.WriteByte(')')
+	}
+	p.directives(op.Directives)
+	p.WriteByte(' ')
+	p.selectionSet(op.SelectionSet)
+}
+
+func (p *printer) variableDef(v ast.VariableDef) {
+	p.WriteString("$" + v.Name + ": ")
+	p.typ(v.Type)
+	if v.DefaultValue != nil {
+		p.WriteString(" = ")
+		p.value(*v.DefaultValue)
+	}
+	p.directives(v.Directives)
+}
+
+func (p *printer) fragmentDef(frag ast.FragmentDef) {
+	p.comments(frag.Comments)
+	p.WriteString("fragment " + frag.Name + " on " + frag.Type)
+	p.directives(frag.Directives)
+	p.WriteByte(' ')
+	p.selectionSet(frag.SelectionSet)
+}
+
+func (p *printer) selectionSet(sel []ast.Selection) {
+	p.WriteByte('{')
+	p.indent++
+	for _, s := range sel {
+		p.newline()
+		p.selection(s)
+	}
+	p.indent--
+	p.newline()
+	p.WriteByte('}')
+}
+
+func (p *printer) selection(s ast.Selection) {
+	switch {
+	case s.Field != nil:
+		p.comments(s.Field.Comments)
+		p.field(*s.Field)
+	case s.FragmentSpread != nil:
+		p.comments(s.FragmentSpread.Comments)
+		p.WriteString("..." + s.FragmentSpread.Name)
+		p.directives(s.FragmentSpread.Directives)
+	case s.InlineFragment != nil:
+		p.comments(s.InlineFragment.Comments)
+		p.WriteString("...")
+		if s.InlineFragment.Type != nil {
+			p.WriteString(" on " + *s.InlineFragment.Type)
+		}
+		p.directives(s.InlineFragment.Directives)
+		p.WriteByte(' ')
+		p.selectionSet(s.InlineFragment.SelectionSet)
+	}
+}
+
+func (p *printer) field(f ast.Field) {
+	if f.Alias != nil {
+		p.WriteString(*f.Alias + ": ")
+	}
+	p.WriteString(f.Name)
+	p.arguments(f.Arguments)
+	p.directives(f.Directives)
+	if len(f.SelectionSet) > 0 {
+		p.WriteByte(' ')
+		p.selectionSet(f.SelectionSet)
+	}
+}
+
+func (p *printer) arguments(args ast.Arguments) {
+	if len(args) == 0 {
+		return
+	}
+	p.WriteByte('(')
+	p.fields(args)
+	p.WriteByte(')')
+}
+
+// fields prints the arguments or object fields of args, separated by commas
+func (p *printer) fields(args ast.Arguments) {
+	for i, arg := range args {
+		if i > 0 {
+			p.WriteString(", ")
+		}
+		p.WriteString(arg.Name + ": ")
+		p.value(arg.Value)
+	}
+}
+
+func (p *printer) directives(directives []ast.Directive) {
+	for _, d := range directives {
+		p.WriteByte(' ')
+		p.directive(d)
+	}
+}
+
+func (p *printer) directive(d ast.Directive) {
+	p.WriteString("@" + d.Name)
+	p.arguments(d.Arguments)
+}
+
+func (p *printer) value(v ast.Value) {
+	switch {
+	case v.Variable != nil:
+		p.WriteString("$" + *v.Variable)
+	case v.Int != nil:
+		p.WriteString(strconv.Itoa(*v.Int))
+	case v.Float != nil:
+		p.WriteString(formatFloat(*v.Float))
+	case v.String != nil:
+		p.WriteString(quote(*v.String))
+	case v.Bool != nil:
+		p.WriteString(strconv.FormatBool(*v.Bool))
+	case v.Enum != nil:
+		p.WriteString(*v.Enum)
+	case v.List != nil:
+		p.WriteByte('[')
+		for i, item := range v.List {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			p.value(item)
+		}
+		p.WriteByte(']')
+	case v.Object != nil:
+		p.WriteByte('{')
+		p.fields(v.Object)
+		p.WriteByte('}')
+	default:
+		p.WriteString("null")
+	}
+}
+
+func (p *printer) typ(t ast.Type) {
+	switch {
+	case t.NonNullType != nil:
+		p.typ(*t.NonNullType)
+		p.WriteByte('!')
+	case t.ListType != nil:
+		p.WriteByte('[')
+		p.typ(*t.ListType)
+		p.WriteByte(']')
+	case t.Name != nil:
+		p.WriteString(*t.Name)
+	}
+}
+
+func (p *printer) schema(s ast.Schema) {
+	p.comments(s.Comments)
+	p.WriteString("schema")
+	p.directives(s.Directives)
+	p.rootOperationTypes(s.RootOperationTypeDefs)
+}
+
+func (p *printer) schemaExtension(s ast.Schema) {
+	p.comments(s.Comments)
+	p.WriteString("extend schema")
+	p.directives(s.Directives)
+	if len(s.RootOperationTypeDefs) > 0 {
+		p.rootOperationTypes(s.RootOperationTypeDefs)
+	}
+}
+
+func (p *printer) rootOperationTypes(defs []ast.RootOperationTypeDef) {
+	p.WriteString(" {")
+	p.indent++
+	for _, def := range defs {
+		p.newline()
+		p.comments(def.Comments)
+		p.WriteString(def.OpType + ": " + def.NamedType)
+	}
+	p.indent--
+	p.newline()
+	p.WriteByte('}')
+}
+
+func (p *printer) typeExtension(ext ast.TypeExtension) {
+	p.comments(ext.Comments)
+	p.comments(ext.TypeDef.Node().Comments)
+	p.WriteString("extend ")
+	p.typeDefBody(ext.TypeDef)
+}
+
+func (p *printer) typeDef(def ast.TypeDef) {
+	p.comments(def.Node().Comments)
+	p.typeDefBody(def)
+}
+
+// typeDefBody prints def without its comments
+func (p *printer) typeDefBody(def ast.TypeDef) {
+	switch {
+	case def.ScalarDef != nil:
+		d := def.ScalarDef
+		p.description(d.Description)
+		p.WriteString("scalar " + d.Name)
+		p.directives(d.Directives)
+	case def.ObjectTypeDef != nil:
+		d := def.ObjectTypeDef
+		p.description(d.Description)
+		p.WriteString("type " + d.Name)
+		p.implements(d.ImplementsInterface)
+		p.directives(d.Directives)
+		p.fieldDefs(d.Fields)
+	case def.InterfaceDef != nil:
+		d := def.InterfaceDef
+		p.description(d.Description)
+		p.WriteString("interface " + d.Name)
+		p.implements(d.ImplementsInterface)
+		p.directives(d.Directives)
+		p.fieldDefs(d.Fields)
+	case def.UnionDef != nil:
+		d := def.UnionDef
+		p.description(d.Description)
+		p.WriteString("union " + d.Name)
+		p.directives(d.Directives)
+		if len(d.Types) > 0 {
+			p.WriteString(" = " + strings.Join(d.Types, " | "))
+		}
+	case def.EnumDef != nil:
+		d := def.EnumDef
+		p.description(d.Description)
+		p.WriteString("enum " + d.Name)
+		p.directives(d.Directives)
+		if len(d.Values) > 0 {
+			p.WriteString(" {")
+			p.indent++
+			for _, v := range d.Values {
+				p.newline()
+				p.comments(v.Comments)
+				p.description(v.Description)
+				p.WriteString(v.Name)
+				p.directives(v.Directives)
+			}
+			p.indent--
+			p.newline()
+			p.WriteByte('}')
+		}
+	case def.InputDef != nil:
+		d := def.InputDef
+		p.description(d.Description)
+		p.WriteString("input " + d.Name)
+		p.directives(d.Directives)
+		if len(d.Fields) > 0 {
+			p.WriteString(" {")
+			p.indent++
+			for _, f := range d.Fields {
+				p.newline()
+				p.inputValueDef(f)
+			}
+			p.indent--
+			p.newline()
+			p.WriteByte('}')
+		}
+	}
+}
+
+func (p *printer) implements(interfaces []string) {
+	if len(interfaces) > 0 {
+		p.WriteString(" implements " + strings.Join(interfaces, " & "))
+	}
+}
+
+func (p *printer) fieldDefs(fields []ast.FieldDef) {
+	if len(fields) == 0 {
+		return
+	}
+	p.WriteString(" {")
+	p.indent++
+	for _, f := range fields {
+		p.newline()
+		p.comments(f.Comments)
+		p.description(f.Description)
+		p.WriteString(f.Name)
+		p.argumentDefs(f.Arguments)
+		p.WriteString(": ")
+		p.typ(f.Type)
+		p.directives(f.Directives)
+	}
+	p.indent--
+	p.newline()
+	p.WriteByte('}')
+}
+
+// argumentDefs prints argument definitions on one line, or one per line
+// when any of them has a description or comments
+func (p *printer) argumentDefs(args []ast.InputValueDef) {
+	if len(args) == 0 {
+		return
+	}
+
+	multiline := false
+	for _, arg := range args {
+		if arg.Description != nil || len(arg.Comments) > 0 {
+			multiline = true
+		}
+	}
+
+	p.WriteByte('(')
+	if multiline {
+		p.indent++
+		for _, arg := range args {
+			p.newline()
+			p.inputValueDef(arg)
+		}
+		p.indent--
+		p.newline()
+	} else {
+		for i, arg := range args {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			p.inputValueDef(arg)
+		}
+	}
+	p.WriteByte(')')
+}
+
+func (p *printer) inputValueDef(v ast.InputValueDef) {
+	p.comments(v.Comments)
+	p.description(v.Description)
+	p.WriteString(v.Name + ": ")
+	p.typ(v.Type)
+	if v.DefaultValue != nil {
+		p.WriteString(" = ")
+		p.value(*v.DefaultValue)
+	}
+	p.directives(v.Directives)
+}
+
+func (p *printer) directiveDef(d ast.DirectiveDef) {
+	p.comments(d.Comments)
+	p.description(d.Description)
+	p.WriteString("directive @" + d.Name)
+	p.argumentDefs(d.Arguments)
+	if d.Repeatable {
+		p.WriteString(" repeatable")
+	}
+	p.WriteString(" on " + strings.Join(d.Locations, " | "))
+}
+
+// comments prints each comment followed by a newline at the current indentation
+func (p *printer) comments(comments []string) {
+	for _, c := range comments {
+		p.WriteString(c)
+		p.newline()
+	}
+}
+
+// trailingComments prints the comments at the end of a document, after a
+// blank line
+func (p *printer) trailingComments(comments []string) {
+	if len(comments) > 0 {
+		p.WriteByte('\n')
+	}
+	for _, c := range comments {
+		p.WriteString("\n" + c)
+	}
+}
+
+// description prints desc as a block string followed by a newline at the
+// current indentation
+func (p *printer) description(desc *string) {
+	if desc == nil {
+		return
+	}
+	p.WriteString(blockString(*desc, p.indent))
+	p.newline()
+}
+
+// blockString quotes s as a block string. Strings spanning several lines
+// start and end on lines of their own, with every line indented
+func blockString(s string, indent int) string {
+	s = strings.Replace(s, `"""`, `\"""`, -1)
+	if !strings.Contains(s, "\n") && !strings.HasSuffix(s, `"`) && !strings.HasSuffix(s, `\`) {
+		return `"""` + s + `"""`
+	}
+
+	prefix := "\n" + strings.Repeat("  ", indent)
+	lines := strings.Split(s, "\n")
+	var b strings.Builder
+	b.WriteString(`"""`)
+	for _, line := range lines {
+		if line == "" {
+			b.WriteByte('\n')
+			continue
+		}
+		b.WriteString(prefix + line)
+	}
+	b.WriteString(prefix + `"""`)
+	return b.String()
+}
+
+// quote returns s as a GraphQL string literal
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// formatFloat formats f so that it is read back as a float rather than an int
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
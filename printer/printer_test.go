@@ -0,0 +1,238 @@
+package printer_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/parser"
+	"github.com/dianelooney/graphql/printer"
+)
+
+func parse(t *testing.T, src string) ast.Document {
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+	return doc
+}
+
+// expectPrint checks that src prints as expected, and that the printed source
+// parses back into a document that prints the same
+func expectPrint(t *testing.T, src string, expected string) {
+	out := printer.Sprint(parse(t, src))
+	if out != expected {
+		t.Errorf("Sprint(%s) returned\n%s\nexpected\n%s", src, out, expected)
+	}
+	if again := printer.Sprint(parse(t, out)); again != out {
+		t.Errorf("Sprint did not round trip, printed\n%s\nthen\n%s", out, again)
+	}
+}
+
+func TestPrintExecutable(t *testing.T) {
+	expectPrint(t, `{ a b: c(y: 2, x: [1, 2.5, "s\"q\u00e9"]) { ...F ... on T @skip(if: true) { d } } }`, `{
+  a
+  b: c(y: 2, x: [1, 2.5, "s\"qé"]) {
+    ...F
+    ... on T @skip(if: true) {
+      d
+    }
+  }
+}
+`)
+	expectPrint(t, `
+	query Q @dir { e(o: {b: B, a: null}) }
+	mutation M { f }
+	fragment F on T { g }
+	`, `query Q @dir {
+  e(o: {b: B, a: null})
+}
+
+mutation M {
+  f
+}
+
+fragment F on T {
+  g
+}
+`)
+}
+
+func TestPrintSchema(t *testing.T) {
+	expectPrint(t, `
+	schema { query: Q }
+	"A scalar" scalar S @specifiedBy(url: "x")
+	"""
+	Multiple
+	  lines
+	"""
+	type Q implements I & J @key {
+		"the field" f(a: Int = 1, b: [S!]!): [Q]!
+		g(
+			"an arg" c: E
+		): E @deprecated
+	}
+	interface I implements J & K { f: [Q]! }
+	union U = Q | R
+	enum E { A "b" B }
+	input In { x: Int! = 3 }
+	directive @key(fields: String) repeatable on OBJECT | INTERFACE
+	`, `schema {
+  query: Q
+}
+
+"""A scalar"""
+scalar S @specifiedBy(url: "x")
+
+"""
+Multiple
+  lines
+"""
+type Q implements I & J @key {
+  """the field"""
+  f(a: Int = 1, b: [S!]!): [Q]!
+  g(
+    """an arg"""
+    c: E
+  ): E @deprecated
+}
+
+interface I implements J & K {
+  f: [Q]!
+}
+
+union U = Q | R
+
+enum E {
+  A
+  """b"""
+  B
+}
+
+input In {
+  x: Int! = 3
+}
+
+directive @key(fields: String) repeatable on OBJECT | INTERFACE
+`)
+}
+
+func TestPrintComments(t *testing.T) {
+	src := `# the query type
+type Query {
+  # a field
+  a(
+    # an argument
+    x: Int, y: Int): Int
+}
+
+# an extension
+extend type Query { b: Int }
+
+query Q {
+  # selected
+  a
+  ...F
+}
+# the end
+`
+	p := parser.Parser{KeepTrivia: true}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	expected := `# the query type
+type Query {
+  # a field
+  a(
+    # an argument
+    x: Int
+    y: Int
+  ): Int
+}
+
+# an extension
+extend type Query {
+  b: Int
+}
+
+query Q {
+  # selected
+  a
+  ...F
+}
+
+# the end
+`
+	if out := printer.Sprint(doc); out != expected {
+		t.Errorf("Sprint returned\n%s\nexpected\n%s", out, expected)
+	}
+}
+
+func TestPrintExtensions(t *testing.T) {
+	expectPrint(t, `
+	extend schema @a { mutation: M }
+	extend type T implements I { f: Int }
+	extend union U = A
+	extend schema @b
+	`, `extend schema @a {
+  mutation: M
+}
+
+extend type T implements I {
+  f: Int
+}
+
+extend union U = A
+
+extend schema @b
+`)
+}
+
+func TestPrintNodes(t *testing.T) {
+	n := "Int"
+	list := ast.Type{ListType: &ast.Type{NonNullType: &ast.Type{Name: &n}}}
+	if out := printer.Sprint(ast.Type{NonNullType: &list}); out != "[Int!]!" {
+		t.Errorf("Sprint of a type returned %s", out)
+	}
+
+	f := 1.0
+	s := "tab\there"
+	if out := printer.Sprint(ast.Value{List: []ast.Value{{Float: &f}, {String: &s}, {IsNull: true}}}); out != `[1.0, "tab\there", null]` {
+		t.Errorf("Sprint of a value returned %s", out)
+	}
+
+	v := "v"
+	op := ast.Operation{OpType: "query", Name: &v, Variables: []ast.VariableDef{
+		{Name: "v", Type: ast.Type{Name: &n}, DefaultValue: &ast.Value{Enum: &v}},
+	}}
+	if out := printer.Sprint(op); out != "query v($v: Int = v) {\n}" {
+		t.Errorf("Sprint of an operation returned %s", out)
+	}
+
+	desc := "Multiple\n\nlines"
+	enum := ast.EnumDef{Name: "E", Values: []ast.EnumValueDef{{Name: "A", Description: &desc}}}
+	if out := printer.Sprint(enum); out != "enum E {\n  \"\"\"\n  Multiple\n\n  lines\n  \"\"\"\n  A\n}" {
+		t.Errorf("Sprint of an enum returned %s", out)
+	}
+
+	q, m := "Q", "M"
+	doc := ast.Document{Operations: map[string]ast.Operation{
+		"Q": {OpType: "query", Name: &q, SelectionSet: []ast.Selection{{Field: &ast.Field{Name: "a", Arguments: ast.Arguments{
+			{Name: "y", Value: ast.Value{Enum: &v}},
+			{Name: "x", Value: ast.Value{IsNull: true}},
+		}}}}},
+		"M": {OpType: "mutation", Name: &m},
+	}}
+	if out := printer.Sprint(doc); out != "mutation M {\n}\n\nquery Q {\n  a(y: v, x: null)\n}\n" {
+		t.Errorf("Sprint of a document without definitions returned %s", out)
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, 42); err == nil {
+		t.Errorf("Fprint of an int did not return an error")
+	}
+}
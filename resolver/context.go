@@ -0,0 +1,58 @@
+package resolver
+
+import "context"
+
+// QueryContext is a Query that also receives the context of the request
+type QueryContext interface {
+	ResolveContext(ctx context.Context, args Args) (result interface{}, err error)
+}
+
+// ObjectContext is an Object that also receives the context of the request
+type ObjectContext interface {
+	ResolveContext(ctx context.Context, field string, args Args) (result interface{}, err error)
+}
+
+// ArrayContext is an Array that also receives the context of the request
+type ArrayContext interface {
+	Len() int
+	GetContext(ctx context.Context, i int) (result interface{}, err error)
+}
+
+// AdaptQuery returns a QueryContext that calls q, ignoring the context
+func AdaptQuery(q Query) QueryContext {
+	return queryAdapter{q}
+}
+
+// AdaptObject returns an ObjectContext that calls o, ignoring the context
+func AdaptObject(o Object) ObjectContext {
+	return objectAdapter{o}
+}
+
+// AdaptArray returns an ArrayContext that calls a, ignoring the context
+func AdaptArray(a Array) ArrayContext {
+	return arrayAdapter{a}
+}
+
+type queryAdapter struct {
+	Query
+}
+
+func (q queryAdapter) ResolveContext(ctx context.Context, args Args) (result interface{}, err error) {
+	return q.Resolve(args)
+}
+
+type objectAdapter struct {
+	Object
+}
+
+func (o objectAdapter) ResolveContext(ctx context.Context, field string, args Args) (result interface{}, err error) {
+	return o.Resolve(field, args)
+}
+
+type arrayAdapter struct {
+	Array
+}
+
+func (a arrayAdapter) GetContext(ctx context.Context, i int) (result interface{}, err error) {
+	return a.Get(i)
+}
@@ -12,6 +12,9 @@ type Query interface {
 }
 
 // Scalar represents a value that can be resolved as a scalar
+//   sc is the (potentially nil) Args of the field the Scalar was returned for
+//   result is the value of the field, which is completed like any value a
+//   resolver returns, and can be nil to express a null value
 type Scalar func(sc interface{}) (result interface{}, err error)
 
 // Object represents a map
@@ -24,3 +27,9 @@ type Array interface {
 	Len() int
 	Get(i int) (result interface{}, err error)
 }
+
+// Typed is implemented by Objects that know the name of their GraphQL type
+// It is used to decide which fragments apply to the Object
+type Typed interface {
+	TypeName() string
+}
@@ -1,11 +1,12 @@
 package resolver
 
 import (
+	"context"
 	"errors"
 	"reflect"
 )
 
-// Reflect implements Object
+// Reflect implements Object and ObjectContext
 // It uses reflection to determine the value to resolve to
 type Reflect struct {
 	Target interface{}
@@ -20,46 +21,51 @@ type Reflect struct {
 // * Method r.MyField
 // * Field MyField
 //
+// Methods may take a context.Context, the Args, both (in that order) or neither.
+// When called through Resolve, the context is context.Background()
+//
 // If no match is found, return an error
 func (r Reflect) Resolve(field string, args Args) (result interface{}, err error) {
+	return r.ResolveContext(context.Background(), field, args)
+}
+
+// ResolveContext is Resolve, passing ctx to methods whose first parameter is a context.Context
+func (r Reflect) ResolveContext(ctx context.Context, field string, args Args) (result interface{}, err error) {
 	val := reflect.ValueOf(r.Target)
 	typ := val.Type()
 
 	if _, ok := typ.MethodByName("Get" + field); ok {
 		m := val.MethodByName("Get" + field)
-		return call(m, args)
+		return call(ctx, m, args)
 	}
 	if _, ok := typ.MethodByName(field); ok {
 		m := val.MethodByName(field)
-		return call(m, args)
+		return call(ctx, m, args)
 	}
-	if _, ok := typ.FieldByName(field); ok {
-		return val.FieldByName(field).Interface(), nil
+	if elem := reflect.Indirect(val); elem.Kind() == reflect.Struct {
+		if _, ok := elem.Type().FieldByName(field); ok {
+			return elem.FieldByName(field).Interface(), nil
+		}
 	}
 
 	return nil, errors.New("missing field")
 }
-func call(m reflect.Value, args Args) (result interface{}, err error) {
-	switch m.Type().NumIn() {
-	case 0:
-		return call0(m)
-	case 1:
-		return call1(m, args)
-	default:
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+func call(ctx context.Context, m reflect.Value, args Args) (result interface{}, err error) {
+	typ := m.Type()
+	in := make([]reflect.Value, 0, typ.NumIn())
+	if len(in) < typ.NumIn() && typ.In(len(in)) == contextType {
+		in = append(in, reflect.ValueOf(&ctx).Elem())
+	}
+	if len(in) < typ.NumIn() {
+		in = append(in, reflect.ValueOf(args))
+	}
+	if len(in) != typ.NumIn() {
 		return nil, errors.New("unexpected input args")
 	}
-}
-func call0(m reflect.Value) (result interface{}, err error) {
-	defer func() {
-		e := recover()
-		if e != nil {
-			err = errors.New("panic while calling method")
-		}
-	}()
 
-	return coerceOutput(m.Call([]reflect.Value{}))
-}
-func call1(m reflect.Value, args Args) (result interface{}, err error) {
 	defer func() {
 		e := recover()
 		if e != nil {
@@ -67,7 +73,7 @@ func call1(m reflect.Value, args Args) (result interface{}, err error) {
 		}
 	}()
 
-	return coerceOutput(m.Call([]reflect.Value{reflect.ValueOf(args)}))
+	return coerceOutput(m.Call(in))
 }
 func coerceOutput(out []reflect.Value) (result interface{}, err error) {
 	if len(out) == 1 {
@@ -1,6 +1,7 @@
 package resolver_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/dianelooney/graphql/resolver"
@@ -22,6 +23,12 @@ func (X) D(args map[string]interface{}) string {
 func (X) E() (string, error) {
 	return "E value", nil
 }
+func (X) F(ctx context.Context) string {
+	return "F value"
+}
+func (X) G(ctx context.Context, args map[string]interface{}) (string, error) {
+	return "G value", nil
+}
 func TestReflect(t *testing.T) {
 	r := resolver.Reflect{
 		Target: X{"A value"},
@@ -32,6 +39,8 @@ func TestReflect(t *testing.T) {
 		"C": "C value",
 		"D": "D value",
 		"E": "E value",
+		"F": "F value",
+		"G": "G value",
 	}
 	for k, v := range tests {
 		res, err := r.Resolve(k, nil)
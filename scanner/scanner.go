@@ -1,9 +1,6 @@
 package scanner
 
-import (
-	"bytes"
-	"regexp"
-)
+import "unicode/utf8"
 
 type Token int
 
@@ -39,300 +36,394 @@ const (
 	AMP
 )
 
+// Position is a location in the source
+//
+// Line and Column start at 1. Column counts bytes from the start of the
+// line, UTF16Column counts UTF-16 code units as editors and GraphQL error
+// locations do. Offset is the byte offset from the start of the source
 type Position struct {
-	Line   int
-	Offset int
+	Line        int
+	Column      int
+	UTF16Column int
+	Offset      int
 }
 
+// Scanner splits a source into tokens, lexing them one at a time as they
+// are asked for
+//
+// Literals are substrings of a single copy of the source, so scanning
+// allocates no memory per token
 type Scanner struct {
-	src    []byte
+	// KeepTrivia makes the scanner record the whitespace, line terminators,
+	// commas and comments before every token. It must be set before Init
+	KeepTrivia bool
+
+	src    string
 	offset int
-	data   []result
-	idx    int
+	line   int
+	col    int
+	col16  int
+
+	// ahead holds the tokens lexed by Peek and PeekN that Scan has not
+	// returned yet, from index head
+	ahead []result
+	head  int
+	end   Position
+
+	// trivia is the trivia before the token last returned by Scan
+	trivia []Trivia
 }
-type scanFunc func(s *Scanner) (token Token, lit string)
 
-var skipFuncs = [...]scanFunc{
-	(*Scanner).scanWhitespace,
-	(*Scanner).scanComment,
-	(*Scanner).scanNewline,
-	(*Scanner).scanComma,
-}
-var scanFuncs = [...]scanFunc{
-	(*Scanner).scanEOF,
-	(*Scanner).scanPunctuator,
-	(*Scanner).scanFloat,
-	(*Scanner).scanInt,
-	(*Scanner).scanBoolean,
-	(*Scanner).scanBlockString,
-	(*Scanner).scanString,
-	(*Scanner).scanName,
+// Trivia is a part of the source that has no meaning to the parser
+//
+// Token is WHITESPACE for a run of spaces, tabs and byte order marks,
+// NEWLINE for a line terminator, COMMA for a comma or COMMENT for a comment
+// running to the end of its line
+type Trivia struct {
+	Pos   Position
+	Token Token
+	Lit   string
 }
 
 type result struct {
-	pos Position
-	tkn Token
-	lit string
+	pos    Position
+	end    Position
+	tkn    Token
+	lit    string
+	trivia []Trivia
 }
 
 func (s *Scanner) Init(src []byte) {
-	s.src = src
+	s.src = string(src)
 	s.offset = 0
-	s.idx = 0
-	s.data = make([]result, 0)
-	for {
-		pos, tkn, lit := s.scan()
-		if tkn == EOF {
-			break
-		}
-
-		s.data = append(s.data, result{pos, tkn, lit})
-	}
+	s.line, s.col, s.col16 = 1, 1, 1
+	s.ahead = s.ahead[:0]
+	s.head = 0
+	s.end = s.position()
+	s.trivia = nil
 }
 
-func (s *Scanner) get(i int) (pos Position, token Token, lit string) {
-	if i >= len(s.data) {
-		token = EOF
-	} else {
-		res := s.data[i]
-		pos, token, lit = res.pos, res.tkn, res.lit
-	}
-
-	return
+func (s *Scanner) position() Position {
+	return Position{Line: s.line, Column: s.col, UTF16Column: s.col16, Offset: s.offset}
 }
 
 func (s *Scanner) Peek() (pos Position, token Token, lit string) {
 	return s.PeekN(0)
 }
 func (s *Scanner) PeekN(n int) (pos Position, token Token, lit string) {
-	return s.get(s.idx + n)
-}
-
-func (s *Scanner) scan() (pos Position, token Token, lit string) {
-	s.skipWhitespace()
-	pos = Position{0, s.offset}
-	for _, f := range scanFuncs {
-		token, lit = f(s)
-
-		if token == ILLEGAL && len(lit) == 0 {
-			continue
-		}
-
-		s.consume(lit)
-		return
+	for len(s.ahead)-s.head <= n {
+		s.ahead = append(s.ahead, s.lex())
 	}
-
-	lit = string(s.src[0])
-	s.consume(lit)
-	return
+	res := s.ahead[s.head+n]
+	return res.pos, res.tkn, res.lit
 }
 
 func (s *Scanner) Scan() (pos Position, token Token, lit string) {
 	pos, token, lit = s.Peek()
-	s.idx++
-
-	return
-}
-
-func (s *Scanner) skipWhitespace() {
-	done := false
-	for !done {
-		done = true
-		for _, f := range skipFuncs {
-			token, lit := f(s)
-			if token == ILLEGAL && len(lit) == 0 {
-				continue
-			}
-
-			s.consume(lit)
-			done = false
-		}
+	if token != EOF {
+		s.end = s.ahead[s.head].end
 	}
-}
-
-func (s *Scanner) consume(lit string) {
-	s.src = s.src[len(lit):]
-	s.offset += len(lit)
-}
-
-func (s *Scanner) scanRegex(r *regexp.Regexp, t Token) (token Token, lit string) {
-	match := r.Find(s.src)
-	if match == nil {
-		return ILLEGAL, ""
+	s.trivia = s.ahead[s.head].trivia
+	s.head++
+	if s.head == len(s.ahead) {
+		s.ahead, s.head = s.ahead[:0], 0
 	}
 
-	return t, string(match)
-}
-
-func (s *Scanner) scanEOF() (token Token, lit string) {
-	if len(s.src) == 0 {
-		return EOF, ""
-	}
-	return ILLEGAL, ""
+	return
 }
 
-var regexComment = regexp.MustCompile(`^(#[^\n]*)`)
-
-func (s *Scanner) scanComment() (token Token, lit string) {
-	return s.scanRegex(regexComment, COMMENT)
+// End returns the position just after the last token returned by Scan
+func (s *Scanner) End() Position {
+	return s.end
 }
 
-var regexWhitespace = regexp.MustCompile(`^([ \t]+)`)
-
-func (s *Scanner) scanWhitespace() (token Token, lit string) {
-	return s.scanRegex(regexWhitespace, WHITESPACE)
+// Trivia returns the trivia before the token last returned by Scan
+// It is only recorded when KeepTrivia is set
+func (s *Scanner) Trivia() []Trivia {
+	return s.trivia
 }
 
-var regexName = regexp.MustCompile(`^([_A-Za-z][_0-9A-Za-z]*)`)
-
-func (s *Scanner) scanName() (token Token, lit string) {
-	return s.scanRegex(regexName, NAME)
+// PeekTrivia returns the trivia before the next token, which is the end of
+// the source at EOF. It is only recorded when KeepTrivia is set
+func (s *Scanner) PeekTrivia() []Trivia {
+	s.Peek()
+	return s.ahead[s.head].trivia
 }
 
-var regexNewline = regexp.MustCompile(`^(\r|\n|\r\n)`)
+// lex scans the token at the current offset
+func (s *Scanner) lex() result {
+	trivia := s.skipIgnored()
+	pos := s.position()
+	if s.offset >= len(s.src) {
+		return result{pos, pos, EOF, "", trivia}
+	}
 
-func (s *Scanner) scanNewline() (token Token, lit string) {
-	return s.scanRegex(regexNewline, NEWLINE)
+	tkn, n := s.lexToken()
+	lit := s.src[s.offset : s.offset+n]
+	switch tkn {
+	case STRING, BLOCKSTRING, ILLEGAL:
+		s.advance(n)
+	default:
+		// the other tokens are ASCII and never span lines
+		s.offset += n
+		s.col += n
+		s.col16 += n
+	}
+	return result{pos, s.position(), tkn, lit, trivia}
 }
 
-var regexComma = regexp.MustCompile(`^(,)`)
+// lexToken returns the kind and the length in bytes of the token at the
+// current offset
+func (s *Scanner) lexToken() (Token, int) {
+	src := s.src[s.offset:]
+	switch c := src[0]; {
+	case c == '"':
+		if len(src) >= 3 && src[1] == '"' && src[2] == '"' {
+			return lexBlockString(src)
+		}
+		return lexString(src)
+	case c == '-' || isDigit(c):
+		return lexNumber(src)
+	case isNameStart(c):
+		n := 1
+		for n < len(src) && isNameContinue(src[n]) {
+			n++
+		}
+		if name := src[:n]; name == "true" || name == "false" {
+			return BOOL, n
+		}
+		return NAME, n
+	case c == '.':
+		if len(src) >= 3 && src[1] == '.' && src[2] == '.' {
+			return ELLIPSIS, 3
+		}
+	case punctuators[c] != ILLEGAL:
+		return punctuators[c], 1
+	}
 
-func (s *Scanner) scanComma() (token Token, lit string) {
-	return s.scanRegex(regexComma, COMMA)
+	// a single illegal character, which may take more than one byte
+	_, n := utf8.DecodeRuneInString(src)
+	return ILLEGAL, n
 }
 
-var regexPunctuator = regexp.MustCompile(`^(\!|\$|\(|\)|\.\.\.|\:|\=|\@|\[|\]|\{|\||\}|&)`)
-var punctuatorMap = map[string]Token{
-	"!":   BANG,
-	"$":   DOLLAR,
-	"(":   LPAREN,
-	")":   RPAREN,
-	"...": ELLIPSIS,
-	":":   COLON,
-	"=":   EQL,
-	"@":   AT,
-	"[":   LSQUARE,
-	"]":   RSQUARE,
-	"{":   LCURLY,
-	"|":   BAR,
-	"}":   RCURLY,
-	"&":   AMP,
+var punctuators = [256]Token{
+	'!': BANG,
+	'$': DOLLAR,
+	'(': LPAREN,
+	')': RPAREN,
+	':': COLON,
+	'=': EQL,
+	'@': AT,
+	'[': LSQUARE,
+	']': RSQUARE,
+	'{': LCURLY,
+	'|': BAR,
+	'}': RCURLY,
+	'&': AMP,
 }
 
-func (s *Scanner) scanPunctuator() (token Token, lit string) {
-	token, lit = s.scanRegex(regexPunctuator, Token(-1))
-	if token != Token(-1) {
-		return ILLEGAL, ""
-	}
-	token, ok := punctuatorMap[lit]
-	if !ok {
-		panic("Unhandled literal matched by scanPunctuator() (it should be added to punctuatorMap): '" + lit + "'")
+// skipIgnored moves past whitespace, line terminators, commas, comments
+// and byte order marks, returning them if KeepTrivia is set
+func (s *Scanner) skipIgnored() (trivia []Trivia) {
+	for s.offset < len(s.src) {
+		start := s.position()
+		var kind Token
+		switch c := s.src[s.offset]; {
+		case c == ' ' || c == '\t' || hasPrefixAt(s.src, s.offset, "\uFEFF"):
+			kind = WHITESPACE
+			n := 0
+			for {
+				if i := s.offset + n; i < len(s.src) && (s.src[i] == ' ' || s.src[i] == '\t') {
+					n++
+				} else if hasPrefixAt(s.src, i, "\uFEFF") {
+					n += 3
+				} else {
+					break
+				}
+			}
+			s.advance(n)
+		case c == ',':
+			kind = COMMA
+			s.advance(1)
+		case c == '\n' || c == '\r':
+			kind = NEWLINE
+			s.advance(1)
+		case c == '#':
+			kind = COMMENT
+			n := 1
+			for s.offset+n < len(s.src) && s.src[s.offset+n] != '\n' && s.src[s.offset+n] != '\r' {
+				n++
+			}
+			s.advance(n)
+		default:
+			return
+		}
+		if s.KeepTrivia {
+			trivia = append(trivia, Trivia{Pos: start, Token: kind, Lit: s.src[start.Offset:s.offset]})
+		}
 	}
-
 	return
 }
 
-var regexInt = regexp.MustCompile(`^(-?(0|[1-9][0-9]*))`)
-
-func (s *Scanner) scanInt() (token Token, lit string) {
-	return s.scanRegex(regexInt, INT)
-}
-
-var regexFloat = regexp.MustCompile(`^((-?(0|[1-9][0-9]*))(\.[0-9]*|[eE][+-]?[0-9]*|\.[0-9]*[eE][+-]?[0-9]*))`)
-
-func (s *Scanner) scanFloat() (token Token, lit string) {
-	return s.scanRegex(regexFloat, FLOAT)
-}
-
-var regexBoolean = regexp.MustCompile(`^(true|false)`)
-
-func (s *Scanner) scanBoolean() (token Token, lit string) {
-	return s.scanRegex(regexBoolean, BOOL)
-}
-
-var escapeSequences = map[byte]struct{}{
-	'"':  struct{}{},
-	'\\': struct{}{},
-	'/':  struct{}{},
-	'b':  struct{}{},
-	'f':  struct{}{},
-	'n':  struct{}{},
-	'r':  struct{}{},
-	't':  struct{}{},
+// advance moves n bytes forward, keeping track of the line and columns
+func (s *Scanner) advance(n int) {
+	end := s.offset + n
+	for s.offset < end {
+		c := s.src[s.offset]
+		switch {
+		case c == '\n':
+			s.offset++
+			s.line, s.col, s.col16 = s.line+1, 1, 1
+		case c == '\r':
+			s.offset++
+			if s.offset < len(s.src) && s.src[s.offset] == '\n' {
+				s.offset++
+			}
+			s.line, s.col, s.col16 = s.line+1, 1, 1
+		case c < utf8.RuneSelf:
+			s.offset++
+			s.col++
+			s.col16++
+		default:
+			r, size := utf8.DecodeRuneInString(s.src[s.offset:])
+			s.offset += size
+			s.col += size
+			if r >= 0x10000 {
+				s.col16 += 2
+			} else {
+				s.col16++
+			}
+		}
+	}
 }
 
-func (s *Scanner) scanString() (token Token, lit string) {
-	if s.src[0] != '"' {
-		return ILLEGAL, ""
+// lexNumber lexes an int or a float. A minus sign that is not followed by
+// a digit is an illegal token
+func lexNumber(src string) (Token, int) {
+	n := 0
+	if src[n] == '-' {
+		n++
+	}
+	if n >= len(src) || !isDigit(src[n]) {
+		return ILLEGAL, n
+	}
+	if src[n] == '0' {
+		n++
+	} else {
+		n = skipDigits(src, n)
 	}
-	for i := 1; i < len(s.src); i++ {
-		if s.src[i] == '"' {
-			return STRING, string(s.src[:i+1])
-		}
 
-		if s.src[i] == '\n' {
-			return ILLEGAL, string(s.src[:i])
+	tkn := INT
+	if n < len(src) && src[n] == '.' {
+		tkn = FLOAT
+		n = skipDigits(src, n+1)
+	}
+	if n < len(src) && (src[n] == 'e' || src[n] == 'E') {
+		tkn = FLOAT
+		n++
+		if n < len(src) && (src[n] == '+' || src[n] == '-') {
+			n++
 		}
+		n = skipDigits(src, n)
+	}
+	return tkn, n
+}
 
-		if s.src[i] == '\r' && i+1 < len(s.src) && s.src[i+1] == '\n' {
-			return ILLEGAL, string(s.src[:i])
-		}
+func skipDigits(src string, n int) int {
+	for n < len(src) && isDigit(src[n]) {
+		n++
+	}
+	return n
+}
 
-		if s.src[i] == '\\' && i+1 < len(s.src) {
-			i++
-			_, ok := escapeSequences[s.src[i]]
-			if ok {
-				continue
+// lexString lexes a string value. A string containing an invalid escape
+// sequence is lexed up to its closing quote, and an unterminated string up
+// to the end of its line, as an ILLEGAL token
+func lexString(src string) (Token, int) {
+	valid := true
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case '"':
+			if !valid {
+				return ILLEGAL, i + 1
 			}
-			if s.src[i] != 'u' {
-				return ILLEGAL, string(s.src[:i+2])
+			return STRING, i + 1
+		case '\n', '\r':
+			return ILLEGAL, i
+		case '\\':
+			if i+1 >= len(src) {
+				continue
 			}
-			if i+4 < len(s.src) {
-				return ILLEGAL, string(s.src[:i+5])
+			i++
+			switch src[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				continue
+			case 'u':
+			default:
+				valid = false
+				continue
 			}
 
-			testChar := func(b byte) bool {
-				if b >= 'a' && b <= 'f' {
-					return true
-				}
-				if b >= 'A' && b <= 'F' {
-					return true
+			// variable width \u{1F600}
+			if i+1 < len(src) && src[i+1] == '{' {
+				j := i + 2
+				for j < len(src) && isHexDigit(src[j]) {
+					j++
 				}
-				if b >= '0' && b <= '9' {
-					return true
+				if j == i+2 || j >= len(src) || src[j] != '}' {
+					valid = false
+					i = j - 1
+					continue
 				}
-				return false
+				i = j
+				continue
 			}
 
-			if testChar(s.src[i+1]) &&
-				testChar(s.src[i+2]) &&
-				testChar(s.src[i+3]) &&
-				testChar(s.src[i+4]) {
+			// fixed width \u00e9
+			if i+4 < len(src) &&
+				isHexDigit(src[i+1]) &&
+				isHexDigit(src[i+2]) &&
+				isHexDigit(src[i+3]) &&
+				isHexDigit(src[i+4]) {
 				i += 4
 				continue
 			}
-			return ILLEGAL, string(s.src[:i+1])
+			valid = false
 		}
 	}
-	return ILLEGAL, ""
+	return ILLEGAL, len(src)
 }
 
-var tripleQuote = []byte(`"""`)
-var escapedTripleQuote = []byte(`\"""`)
-
-func (s *Scanner) scanBlockString() (token Token, lit string) {
-	if !bytes.HasPrefix(s.src, tripleQuote) {
-		return ILLEGAL, ""
-	}
-
-	for i := 3; i < len(s.src); i++ {
-		if bytes.HasPrefix(s.src[i:], tripleQuote) {
-			return BLOCKSTRING, string(s.src[0 : i+3])
-		}
-
-		if bytes.HasPrefix(s.src[i:], escapedTripleQuote) {
+// lexBlockString lexes a block string. An unterminated block string is an
+// ILLEGAL token running to the end of the source
+func lexBlockString(src string) (Token, int) {
+	for i := 3; i < len(src); i++ {
+		switch {
+		case hasPrefixAt(src, i, `"""`):
+			return BLOCKSTRING, i + 3
+		case hasPrefixAt(src, i, `\"""`):
 			i += 3
 		}
 	}
-	return ILLEGAL, ""
+	return ILLEGAL, len(src)
+}
+
+func hasPrefixAt(s string, i int, prefix string) bool {
+	return len(s)-i >= len(prefix) && s[i:i+len(prefix)] == prefix
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isNameStart(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '_'
+}
+
+func isNameContinue(b byte) bool {
+	return isNameStart(b) || isDigit(b)
+}
+
+func isHexDigit(b byte) bool {
+	return b >= 'a' && b <= 'f' || b >= 'A' && b <= 'F' || b >= '0' && b <= '9'
 }
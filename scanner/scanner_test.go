@@ -1,6 +1,9 @@
 package scanner_test
 
 import (
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/dianelooney/graphql/scanner"
@@ -68,4 +71,155 @@ thing"""`)
 "\"something
 `))
 	expectScanResult(t, s, scanner.ILLEGAL, `"\"something`)
+
+	s = &scanner.Scanner{}
+	s.Init([]byte(`"\u{1F600}\u00e9" "bad \q escape" "\u{}" "\u12"`))
+	expectScanResult(t, s, scanner.STRING, `"\u{1F600}\u00e9"`)
+	expectScanResult(t, s, scanner.ILLEGAL, `"bad \q escape"`)
+	expectScanResult(t, s, scanner.ILLEGAL, `"\u{}"`)
+	expectScanResult(t, s, scanner.ILLEGAL, `"\u12"`)
+
+	s = &scanner.Scanner{}
+	s.Init([]byte("\uFEFFtrue trueValue false_ 1.5e-3 0.25E+2 - # comment\r& .. é \"\"\"open"))
+	expectScanResult(t, s, scanner.BOOL, `true`)
+	expectScanResult(t, s, scanner.NAME, `trueValue`)
+	expectScanResult(t, s, scanner.NAME, `false_`)
+	expectScanResult(t, s, scanner.FLOAT, `1.5e-3`)
+	expectScanResult(t, s, scanner.FLOAT, `0.25E+2`)
+	expectScanResult(t, s, scanner.ILLEGAL, `-`)
+	expectScanResult(t, s, scanner.AMP, `&`)
+	expectScanResult(t, s, scanner.ILLEGAL, `.`)
+	expectScanResult(t, s, scanner.ILLEGAL, `.`)
+	expectScanResult(t, s, scanner.ILLEGAL, `é`)
+	expectScanResult(t, s, scanner.ILLEGAL, `"""open`)
+	expectScanResult(t, s, scanner.EOF, ``)
+}
+
+func TestPeek(t *testing.T) {
+	s := &scanner.Scanner{}
+	s.Init([]byte("a b c"))
+	if _, _, lit := s.PeekN(2); lit != "c" {
+		t.Errorf("Expected PeekN(2) to return c, got %q", lit)
+	}
+	if _, _, lit := s.Peek(); lit != "a" {
+		t.Errorf("Expected Peek to return a, got %q", lit)
+	}
+	expectScanResult(t, s, scanner.NAME, "a")
+	if _, _, lit := s.PeekN(1); lit != "c" {
+		t.Errorf("Expected PeekN(1) to return c after a Scan, got %q", lit)
+	}
+	expectScanResult(t, s, scanner.NAME, "b")
+	expectScanResult(t, s, scanner.NAME, "c")
+	if _, tkn, _ := s.PeekN(3); tkn != scanner.EOF {
+		t.Errorf("Expected to peek EOF past the end, got %v", tkn)
+	}
+	expectScanResult(t, s, scanner.EOF, "")
+	if end := s.End(); end.Offset != 5 {
+		t.Errorf("Expected End to stay after c once EOF is scanned, got %+v", end)
+	}
+}
+
+func TestTrivia(t *testing.T) {
+	s := &scanner.Scanner{KeepTrivia: true}
+	s.Init([]byte("\ufeff a ,\r\n\t# c\nb # end"))
+	s.Scan()
+	expected := []scanner.Trivia{
+		{Pos: scanner.Position{Offset: 0, Line: 1, Column: 1, UTF16Column: 1}, Token: scanner.WHITESPACE, Lit: "\ufeff "},
+	}
+	if trivia := s.Trivia(); !reflect.DeepEqual(trivia, expected) {
+		t.Errorf("Expected the trivia before a to be %+v, got %+v", expected, trivia)
+	}
+
+	var kinds []scanner.Token
+	var lits []string
+	for _, trivia := range s.PeekTrivia() {
+		kinds = append(kinds, trivia.Token)
+		lits = append(lits, trivia.Lit)
+	}
+	if expected := []scanner.Token{scanner.WHITESPACE, scanner.COMMA, scanner.NEWLINE, scanner.WHITESPACE, scanner.COMMENT, scanner.NEWLINE}; !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected the trivia before b to be %v, got %v", expected, kinds)
+	}
+	if expected := []string{" ", ",", "\r\n", "\t", "# c", "\n"}; !reflect.DeepEqual(lits, expected) {
+		t.Errorf("Expected the trivia before b to be %q, got %q", expected, lits)
+	}
+
+	s.Scan()
+	if trivia := s.PeekTrivia(); len(trivia) != 2 || trivia[1].Lit != "# end" {
+		t.Errorf("Expected to peek the trailing comment before EOF, got %+v", trivia)
+	}
+
+	s = &scanner.Scanner{}
+	s.Init([]byte("# c\na"))
+	s.Scan()
+	if trivia := s.Trivia(); trivia != nil {
+		t.Errorf("Expected no trivia without KeepTrivia, got %+v", trivia)
+	}
+}
+
+func expectPosition(t *testing.T, s *scanner.Scanner, lit string, start, end scanner.Position) {
+	pos, _, l := s.Scan()
+	if l != lit {
+		t.Errorf("Expected to scan a literal '%v', but got '%v'\n", lit, l)
+	}
+	if pos != start {
+		t.Errorf("Expected '%v' to start at %+v, but got %+v\n", lit, start, pos)
+	}
+	if s.End() != end {
+		t.Errorf("Expected '%v' to end at %+v, but got %+v\n", lit, end, s.End())
+	}
+}
+func TestPositions(t *testing.T) {
+	s := &scanner.Scanner{}
+	s.Init([]byte("a\r\n  \"é😀\" b\r\"\"\"x\ny\"\"\" c"))
+	expectPosition(t, s, "a", scanner.Position{Line: 1, Column: 1, UTF16Column: 1, Offset: 0}, scanner.Position{Line: 1, Column: 2, UTF16Column: 2, Offset: 1})
+	expectPosition(t, s, "\"é😀\"", scanner.Position{Line: 2, Column: 3, UTF16Column: 3, Offset: 5}, scanner.Position{Line: 2, Column: 11, UTF16Column: 8, Offset: 13})
+	expectPosition(t, s, "b", scanner.Position{Line: 2, Column: 12, UTF16Column: 9, Offset: 14}, scanner.Position{Line: 2, Column: 13, UTF16Column: 10, Offset: 15})
+	expectPosition(t, s, "\"\"\"x\ny\"\"\"", scanner.Position{Line: 3, Column: 1, UTF16Column: 1, Offset: 16}, scanner.Position{Line: 4, Column: 5, UTF16Column: 5, Offset: 25})
+	expectPosition(t, s, "c", scanner.Position{Line: 4, Column: 6, UTF16Column: 6, Offset: 26}, scanner.Position{Line: 4, Column: 7, UTF16Column: 7, Offset: 27})
+	pos, tkn, _ := s.Scan()
+	if tkn != scanner.EOF || pos != (scanner.Position{Line: 4, Column: 7, UTF16Column: 7, Offset: 27}) {
+		t.Errorf("Expected EOF at 4:7, but got %v at %+v\n", tkn, pos)
+	}
+}
+
+// benchmarkSource is a schema and a query of a few hundred kilobytes,
+// using every kind of token
+func benchmarkSource() []byte {
+	var b strings.Builder
+	for i := 0; i < 500; i++ {
+		n := strconv.Itoa(i)
+		b.WriteString(`"""
+A type with a description
+"""
+type Type` + n + ` implements Node & Named @key(fields: "id") {
+  # a comment
+  id: ID!
+  "the name"
+  name(first: Int = 10, after: String = "cursor\n", ratio: Float = -1.5e3): [String!]!
+  flag: Boolean @deprecated(reason: "use something else")
+}
+
+`)
+	}
+	b.WriteString("query Q($id: ID!, $on: Boolean = true) {\n")
+	for i := 0; i < 500; i++ {
+		b.WriteString(`  alias` + strconv.Itoa(i) + `: node(id: $id, n: [1, 2, 3], o: {a: true, b: null, c: ENUM}) @include(if: $on) { ... on Type { id name } ...Frag }` + "\n")
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func BenchmarkScan(b *testing.B) {
+	src := benchmarkSource()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := &scanner.Scanner{}
+		s.Init(src)
+		for {
+			if _, tkn, _ := s.Scan(); tkn == scanner.EOF {
+				break
+			}
+		}
+	}
 }
@@ -0,0 +1,381 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+)
+
+// Build turns the type system definitions of doc into a linked Schema
+//
+// The built-in scalars and directives are added to the schema, and every
+// type reference is resolved to the type it names. Schema and type
+// extensions are merged into the definitions they extend, which may be
+// built-in ones. Operations and fragments in doc are ignored. If any
+// problem is found, the schema is nil and all of the problems are returned
+func Build(doc ast.Document) (*Schema, []error) {
+	b := builder{
+		s: &Schema{
+			Types:      make(map[string]Type),
+			Directives: make(map[string]*Directive),
+		},
+		types:      make(map[string]ast.TypeDef),
+		directives: make(map[string]ast.DirectiveDef),
+	}
+
+	b.collect(doc)
+	schemaDef := b.extend(doc)
+	b.declareTypes()
+	b.defineTypes()
+	b.defineDirectives()
+	b.defineRoots(schemaDef)
+	b.defineMetaFields()
+	b.validate()
+
+	if len(b.errors) > 0 {
+		return nil, b.errors
+	}
+	return b.s, nil
+}
+
+type builder struct {
+	s          *Schema
+	types      map[string]ast.TypeDef
+	directives map[string]ast.DirectiveDef
+	errors     []error
+}
+
+func (b *builder) errorf(format string, args ...interface{}) {
+	b.errors = append(b.errors, gqlerror.Errorf(format, args...))
+}
+
+// collect gathers the built-in and user definitions, checking for conflicts
+func (b *builder) collect(doc ast.Document) {
+	defined := make(map[string]bool)
+	hasSchema := false
+	for _, def := range doc.Definitions {
+		switch {
+		case def.Schema != nil:
+			if hasSchema {
+				b.errorf("schema can only be defined once")
+			}
+			hasSchema = true
+		case def.Type != nil:
+			if name := def.Type.Name(); defined[name] {
+				b.errorf("type %s can only be defined once", name)
+			}
+			defined[def.Type.Name()] = true
+		case def.Directive != nil:
+			if name := "@" + def.Directive.Name; defined[name] {
+				b.errorf("directive %s can only be defined once", name)
+			}
+			defined["@"+def.Directive.Name] = true
+		}
+	}
+
+	for name, def := range builtins.Types {
+		b.types[name] = def
+	}
+	for name, def := range builtins.Directives {
+		b.directives[name] = def
+	}
+
+	for _, name := range ast.SortedNames(doc.Types) {
+		def := doc.Types[name]
+		if _, ok := builtins.Types[name]; ok && def.ScalarDef == nil {
+			b.errorf("type %s conflicts with the built-in scalar %s", name, name)
+			continue
+		}
+		if strings.HasPrefix(name, "__") {
+			b.errorf("type %s must not begin with \"__\", which is reserved for introspection", name)
+			continue
+		}
+		b.types[name] = def
+	}
+	for _, name := range ast.SortedNames(doc.Directives) {
+		if _, ok := builtins.Directives[name]; ok {
+			b.errorf("directive @%s conflicts with the built-in directive @%s", name, name)
+			continue
+		}
+		if strings.HasPrefix(name, "__") {
+			b.errorf("directive @%s must not begin with \"__\", which is reserved for introspection", name)
+			continue
+		}
+		b.directives[name] = doc.Directives[name]
+	}
+}
+
+// extend merges the extensions of doc into the collected definitions,
+// returning the extended schema definition
+func (b *builder) extend(doc ast.Document) *ast.Schema {
+	merged := ast.Document{
+		Schema:           doc.Schema,
+		Types:            b.types,
+		SchemaExtensions: doc.SchemaExtensions,
+		TypeExtensions:   doc.TypeExtensions,
+	}
+	b.errors = append(b.errors, merged.MergeExtensions()...)
+	b.types = merged.Types
+	return merged.Schema
+}
+
+// declareTypes creates an empty named type for every definition,
+// so that references between the types can be resolved in any order
+func (b *builder) declareTypes() {
+	for name, def := range b.types {
+		switch {
+		case def.ScalarDef != nil:
+			b.s.Types[name] = &Scalar{Name: name}
+		case def.ObjectTypeDef != nil:
+			b.s.Types[name] = &Object{Name: name}
+		case def.InterfaceDef != nil:
+			b.s.Types[name] = &Interface{Name: name}
+		case def.UnionDef != nil:
+			b.s.Types[name] = &Union{Name: name}
+		case def.EnumDef != nil:
+			b.s.Types[name] = &Enum{Name: name}
+		case def.InputDef != nil:
+			b.s.Types[name] = &InputObject{Name: name}
+		}
+	}
+}
+
+func (b *builder) defineTypes() {
+	for _, name := range ast.SortedNames(b.types) {
+		def := b.types[name]
+		switch t := b.s.Types[name].(type) {
+		case *Scalar:
+			t.Description = str(def.ScalarDef.Description)
+			t.Directives = def.ScalarDef.Directives
+		case *Object:
+			t.Description = str(def.ObjectTypeDef.Description)
+			t.Directives = def.ObjectTypeDef.Directives
+			t.Fields = b.fields(name, def.ObjectTypeDef.Fields)
+			t.Interfaces = b.interfaces(name, def.ObjectTypeDef.ImplementsInterface)
+			for _, intf := range t.Interfaces {
+				intf.PossibleTypes = append(intf.PossibleTypes, t)
+			}
+		case *Interface:
+			t.Description = str(def.InterfaceDef.Description)
+			t.Directives = def.InterfaceDef.Directives
+			t.Fields = b.fields(name, def.InterfaceDef.Fields)
+			t.Interfaces = b.interfaces(name, def.InterfaceDef.ImplementsInterface)
+		case *Union:
+			t.Description = str(def.UnionDef.Description)
+			t.Directives = def.UnionDef.Directives
+			seen := make(map[string]bool)
+			for _, member := range def.UnionDef.Types {
+				if seen[member] {
+					b.errorf("union %s can only include type %s once", name, member)
+					continue
+				}
+				seen[member] = true
+				switch m := b.s.Types[member].(type) {
+				case nil:
+					b.errorf("union %s includes unknown type %s", name, member)
+				case *Object:
+					t.Types = append(t.Types, m)
+				default:
+					b.errorf("union %s can only include object types, it cannot include %s", name, member)
+				}
+			}
+		case *Enum:
+			t.Description = str(def.EnumDef.Description)
+			t.Directives = def.EnumDef.Directives
+			for _, v := range def.EnumDef.Values {
+				t.Values = append(t.Values, &EnumValue{
+					Name:        v.Name,
+					Description: str(v.Description),
+					Directives:  v.Directives,
+				})
+			}
+		case *InputObject:
+			t.Description = str(def.InputDef.Description)
+			t.Directives = def.InputDef.Directives
+			t.Fields = b.inputValues(name, def.InputDef.Fields)
+		}
+	}
+}
+
+func (b *builder) fields(parent string, defs []ast.FieldDef) (fields FieldList) {
+	for _, def := range defs {
+		fields = append(fields, &Field{
+			Name:        def.Name,
+			Description: str(def.Description),
+			Args:        b.inputValues(parent+"."+def.Name, def.Arguments),
+			Type:        b.typeRef(parent+"."+def.Name, def.Type),
+			Directives:  def.Directives,
+		})
+	}
+	return
+}
+
+func (b *builder) inputValues(parent string, defs []ast.InputValueDef) (values InputValueList) {
+	for _, def := range defs {
+		values = append(values, &InputValue{
+			Name:         def.Name,
+			Description:  str(def.Description),
+			Type:         b.typeRef(parent+"("+def.Name+":)", def.Type),
+			DefaultValue: def.DefaultValue,
+			Directives:   def.Directives,
+		})
+	}
+	return
+}
+
+func (b *builder) interfaces(parent string, names []string) (interfaces []*Interface) {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			b.errorf("type %s can only implement %s once", parent, name)
+			continue
+		}
+		seen[name] = true
+		if name == parent {
+			b.errorf("interface %s cannot implement itself", parent)
+			continue
+		}
+		switch t := b.s.Types[name].(type) {
+		case nil:
+			b.errorf("type %s implements unknown interface %s", parent, name)
+		case *Interface:
+			interfaces = append(interfaces, t)
+		default:
+			b.errorf("type %s cannot implement non-interface type %s", parent, name)
+		}
+	}
+	return
+}
+
+// typeRef resolves a type reference, reporting unknown types as used by coord
+func (b *builder) typeRef(coord string, ref ast.Type) Type {
+	t := b.s.TypeFromAST(ref)
+	if t == nil {
+		name := NamedTypeName(ref)
+		if name == "" {
+			b.errorf("%s is missing a type", coord)
+		} else {
+			b.errorf("%s refers to unknown type %s", coord, name)
+		}
+	}
+	return t
+}
+
+func (b *builder) defineDirectives() {
+	for _, name := range ast.SortedNames(b.directives) {
+		def := b.directives[name]
+		b.s.Directives[name] = &Directive{
+			Name:        name,
+			Description: str(def.Description),
+			Args:        b.inputValues("@"+name, def.Arguments),
+			Repeatable:  def.Repeatable,
+			Locations:   def.Locations,
+		}
+	}
+}
+
+func (b *builder) defineRoots(schema *ast.Schema) {
+	if schema == nil {
+		b.s.Query, _ = b.s.Types["Query"].(*Object)
+		b.s.Mutation, _ = b.s.Types["Mutation"].(*Object)
+		b.s.Subscription, _ = b.s.Types["Subscription"].(*Object)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, def := range schema.RootOperationTypeDefs {
+		if seen[def.OpType] {
+			b.errorf("schema can only define the %s root type once", def.OpType)
+			continue
+		}
+		seen[def.OpType] = true
+
+		var obj *Object
+		switch t := b.s.Types[def.NamedType].(type) {
+		case nil:
+			b.errorf("%s root type %s is not defined", def.OpType, def.NamedType)
+			continue
+		case *Object:
+			obj = t
+		default:
+			b.errorf("%s root type must be an object type, it cannot be %s", def.OpType, def.NamedType)
+			continue
+		}
+
+		switch def.OpType {
+		case "query":
+			b.s.Query = obj
+		case "mutation":
+			b.s.Mutation = obj
+		case "subscription":
+			b.s.Subscription = obj
+		default:
+			b.errorf("unknown root operation type %s", def.OpType)
+		}
+	}
+}
+
+// defineMetaFields creates the fields that every query can select without
+// them being defined by the schema
+func (b *builder) defineMetaFields() {
+	str := &NonNull{OfType: b.s.Types["String"]}
+	b.s.meta.typename = &Field{
+		Name:        "__typename",
+		Description: "The name of the current Object type at runtime.",
+		Type:        str,
+	}
+	b.s.meta.schema = &Field{
+		Name:        "__schema",
+		Description: "Access the current type schema of this server.",
+		Type:        &NonNull{OfType: b.s.Types["__Schema"]},
+	}
+	b.s.meta.typ = &Field{
+		Name:        "__type",
+		Description: "Request the type information of a single type.",
+		Args:        InputValueList{{Name: "name", Type: str}},
+		Type:        b.s.Types["__Type"],
+	}
+}
+
+// TypeFromAST resolves a type reference against the schema, or returns nil
+// if the named type does not exist
+func (s *Schema) TypeFromAST(ref ast.Type) Type {
+	switch {
+	case ref.NonNullType != nil:
+		t := s.TypeFromAST(*ref.NonNullType)
+		if t == nil {
+			return nil
+		}
+		return &NonNull{OfType: t}
+	case ref.ListType != nil:
+		t := s.TypeFromAST(*ref.ListType)
+		if t == nil {
+			return nil
+		}
+		return &List{OfType: t}
+	case ref.Name != nil:
+		return s.Types[*ref.Name]
+	}
+	return nil
+}
+
+// NamedTypeName returns the name of the named type at the core of ref
+func NamedTypeName(ref ast.Type) string {
+	switch {
+	case ref.NonNullType != nil:
+		return NamedTypeName(*ref.NonNullType)
+	case ref.ListType != nil:
+		return NamedTypeName(*ref.ListType)
+	case ref.Name != nil:
+		return *ref.Name
+	}
+	return ""
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,428 @@
+package schema
+
+import "github.com/dianelooney/graphql/ast"
+
+// Schema is a validated type system, built from the SDL parts of an ast.Document
+type Schema struct {
+	Query        *Object
+	Mutation     *Object
+	Subscription *Object
+
+	Types      map[string]Type
+	Directives map[string]*Directive
+
+	meta struct {
+		typename, schema, typ *Field
+	}
+}
+
+// Type returns the named type called name, or nil if there is none
+func (s *Schema) Type(name string) Type {
+	return s.Types[name]
+}
+
+// RootType returns the root object type for the operation type opType
+// ("query", "mutation" or "subscription"), or nil if the schema does not support it
+func (s *Schema) RootType(opType string) *Object {
+	switch opType {
+	case "query":
+		return s.Query
+	case "mutation":
+		return s.Mutation
+	case "subscription":
+		return s.Subscription
+	}
+	return nil
+}
+
+// PossibleTypes returns the object types that t can be resolved to at runtime
+func (s *Schema) PossibleTypes(t Type) []*Object {
+	switch t := t.(type) {
+	case *Object:
+		return []*Object{t}
+	case *Interface:
+		return t.PossibleTypes
+	case *Union:
+		return t.Types
+	}
+	return nil
+}
+
+// IsPossibleType reports whether obj is one of the possible types of t
+func (s *Schema) IsPossibleType(t Type, obj *Object) bool {
+	for _, o := range s.PossibleTypes(t) {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// TypesOverlap reports whether an object could be of both type a and type b
+func (s *Schema) TypesOverlap(a, b Type) bool {
+	for _, obj := range s.PossibleTypes(a) {
+		if s.IsPossibleType(b, obj) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldDef returns the definition of the field called name on parent, or nil
+//
+// Unlike the Fields of an Object or Interface, it also knows about the
+// __typename meta field of composite types, and the __schema and __type
+// meta fields of the query root type
+func (s *Schema) FieldDef(parent Type, name string) *Field {
+	switch name {
+	case "__typename":
+		if IsCompositeType(parent) {
+			return s.meta.typename
+		}
+	case "__schema":
+		if parent == Type(s.Query) {
+			return s.meta.schema
+		}
+	case "__type":
+		if parent == Type(s.Query) {
+			return s.meta.typ
+		}
+	}
+
+	switch t := parent.(type) {
+	case *Object:
+		return t.Fields.Get(name)
+	case *Interface:
+		return t.Fields.Get(name)
+	}
+	return nil
+}
+
+// Type is one of *Scalar, *Object, *Interface, *Union, *Enum, *InputObject, *List or *NonNull
+type Type interface {
+	// String returns the type as it would be written in a type reference, e.g. [Int!]!
+	String() string
+
+	isType()
+}
+
+// Scalar is a leaf type
+type Scalar struct {
+	Name        string
+	Description string
+	Directives  []ast.Directive
+}
+
+// Object is an object type
+type Object struct {
+	Name        string
+	Description string
+	Interfaces  []*Interface
+	Fields      FieldList
+	Directives  []ast.Directive
+}
+
+// Interface is an abstract type that objects and other interfaces can implement
+type Interface struct {
+	Name        string
+	Description string
+	Interfaces  []*Interface
+	Fields      FieldList
+	Directives  []ast.Directive
+
+	// PossibleTypes are the object types implementing the interface
+	PossibleTypes []*Object
+}
+
+// Union is an abstract type with a fixed list of member types
+type Union struct {
+	Name        string
+	Description string
+	Types       []*Object
+	Directives  []ast.Directive
+}
+
+// Enum is a leaf type with a fixed list of values
+type Enum struct {
+	Name        string
+	Description string
+	Values      []*EnumValue
+	Directives  []ast.Directive
+}
+
+// Value returns the enum value called name, or nil if there is none
+func (e *Enum) Value(name string) *EnumValue {
+	for _, v := range e.Values {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// EnumValue is one of the values of an Enum
+type EnumValue struct {
+	Name        string
+	Description string
+	Directives  []ast.Directive
+}
+
+// Deprecation returns the reason the enum value was deprecated, and whether it was
+func (v *EnumValue) Deprecation() (reason string, deprecated bool) {
+	return Deprecation(v.Directives)
+}
+
+// InputObject is a composite input type
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      InputValueList
+	Directives  []ast.Directive
+}
+
+// List wraps a type to express a list of that type
+type List struct {
+	OfType Type
+}
+
+// NonNull wraps a type to express that it cannot be null
+type NonNull struct {
+	OfType Type
+}
+
+// Field is a field of an Object or Interface
+type Field struct {
+	Name        string
+	Description string
+	Args        InputValueList
+	Type        Type
+	Directives  []ast.Directive
+}
+
+// Deprecation returns the reason the field was deprecated, and whether it was
+func (f *Field) Deprecation() (reason string, deprecated bool) {
+	return Deprecation(f.Directives)
+}
+
+// FieldList is an ordered list of fields
+type FieldList []*Field
+
+// Get returns the field called name, or nil if there is none
+func (l FieldList) Get(name string) *Field {
+	for _, f := range l {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// InputValue is an argument or an input object field
+type InputValue struct {
+	Name         string
+	Description  string
+	Type         Type
+	DefaultValue *ast.Value
+	Directives   []ast.Directive
+}
+
+// Deprecation returns the reason the input value was deprecated, and whether it was
+func (v *InputValue) Deprecation() (reason string, deprecated bool) {
+	return Deprecation(v.Directives)
+}
+
+// InputValueList is an ordered list of arguments or input object fields
+type InputValueList []*InputValue
+
+// Get returns the input value called name, or nil if there is none
+func (l InputValueList) Get(name string) *InputValue {
+	for _, v := range l {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// Directive is a directive definition
+type Directive struct {
+	Name        string
+	Description string
+	Args        InputValueList
+	Repeatable  bool
+	Locations   []string
+}
+
+// HasLocation reports whether the directive may be used at location
+func (d *Directive) HasLocation(location string) bool {
+	for _, l := range d.Locations {
+		if l == location {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Scalar) String() string      { return t.Name }
+func (t *Object) String() string      { return t.Name }
+func (t *Interface) String() string   { return t.Name }
+func (t *Union) String() string       { return t.Name }
+func (t *Enum) String() string        { return t.Name }
+func (t *InputObject) String() string { return t.Name }
+func (t *List) String() string        { return "[" + t.OfType.String() + "]" }
+func (t *NonNull) String() string     { return t.OfType.String() + "!" }
+
+func (*Scalar) isType()      {}
+func (*Object) isType()      {}
+func (*Interface) isType()   {}
+func (*Union) isType()       {}
+func (*Enum) isType()        {}
+func (*InputObject) isType() {}
+func (*List) isType()        {}
+func (*NonNull) isType()     {}
+
+// NamedType strips all List and NonNull wrappers from t
+func NamedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.OfType
+		case *NonNull:
+			t = w.OfType
+		default:
+			return t
+		}
+	}
+}
+
+// Nullable strips a NonNull wrapper from t, if there is one
+func Nullable(t Type) Type {
+	if nn, ok := t.(*NonNull); ok {
+		return nn.OfType
+	}
+	return t
+}
+
+// IsInputType reports whether t can be used for arguments and variables
+func IsInputType(t Type) bool {
+	switch NamedType(t).(type) {
+	case *Scalar, *Enum, *InputObject:
+		return true
+	}
+	return false
+}
+
+// IsOutputType reports whether t can be used for fields
+func IsOutputType(t Type) bool {
+	switch NamedType(t).(type) {
+	case *Scalar, *Object, *Interface, *Union, *Enum:
+		return true
+	}
+	return false
+}
+
+// IsLeafType reports whether t is a Scalar or an Enum
+func IsLeafType(t Type) bool {
+	switch t.(type) {
+	case *Scalar, *Enum:
+		return true
+	}
+	return false
+}
+
+// IsCompositeType reports whether t is an Object, Interface or Union
+func IsCompositeType(t Type) bool {
+	switch t.(type) {
+	case *Object, *Interface, *Union:
+		return true
+	}
+	return false
+}
+
+// IsAbstractType reports whether t is an Interface or Union
+func IsAbstractType(t Type) bool {
+	switch t.(type) {
+	case *Interface, *Union:
+		return true
+	}
+	return false
+}
+
+// IsSubType reports whether sub can be used where super is expected,
+// following the spec's IsValidImplementationFieldType
+func IsSubType(s *Schema, sub, super Type) bool {
+	if nn, ok := super.(*NonNull); ok {
+		subNN, ok := sub.(*NonNull)
+		if !ok {
+			return false
+		}
+		return IsSubType(s, subNN.OfType, nn.OfType)
+	}
+	if nn, ok := sub.(*NonNull); ok {
+		return IsSubType(s, nn.OfType, super)
+	}
+	if l, ok := super.(*List); ok {
+		subL, ok := sub.(*List)
+		if !ok {
+			return false
+		}
+		return IsSubType(s, subL.OfType, l.OfType)
+	}
+	if _, ok := sub.(*List); ok {
+		return false
+	}
+	if sub == super {
+		return true
+	}
+	if obj, ok := sub.(*Object); ok && IsAbstractType(super) {
+		return s.IsPossibleType(super, obj)
+	}
+	if intf, ok := sub.(*Interface); ok {
+		for _, i := range intf.Interfaces {
+			if Type(i) == super {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsEqualType reports whether a and b are the same type reference
+func IsEqualType(a, b Type) bool {
+	switch a := a.(type) {
+	case *NonNull:
+		b, ok := b.(*NonNull)
+		return ok && IsEqualType(a.OfType, b.OfType)
+	case *List:
+		b, ok := b.(*List)
+		return ok && IsEqualType(a.OfType, b.OfType)
+	}
+	return a == b
+}
+
+// Deprecation looks for a @deprecated directive in directives, returning
+// the reason it gives and whether there is one
+func Deprecation(directives []ast.Directive) (reason string, deprecated bool) {
+	for _, d := range directives {
+		if d.Name != "deprecated" {
+			continue
+		}
+		if r, ok := d.Arguments.Get("reason"); ok && r.String != nil {
+			return *r.String, true
+		}
+		return "No longer supported", true
+	}
+	return "", false
+}
+
+// SpecifiedByURL returns the url given by the @specifiedBy directive of a scalar, if any
+func (t *Scalar) SpecifiedByURL() (url string, ok bool) {
+	for _, d := range t.Directives {
+		if d.Name == "specifiedBy" {
+			if u, ok := d.Arguments.Get("url"); ok && u.String != nil {
+				return *u.String, true
+			}
+		}
+	}
+	return "", false
+}
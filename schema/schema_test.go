@@ -0,0 +1,274 @@
+package schema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/parser"
+	"github.com/dianelooney/graphql/schema"
+)
+
+func build(t *testing.T, src string) (*schema.Schema, []error) {
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+	return schema.Build(doc)
+}
+
+func TestBuild(t *testing.T) {
+	s, errs := build(t, `
+	schema { query: Root }
+	type Root {
+		pet(id: ID!): Pet
+		search(term: String = "x"): [SearchResult!]!
+	}
+	"a pet"
+	interface Pet { name: String }
+	type Dog implements Pet { name: String! barks: Boolean }
+	type Cat implements Pet { name: String meows: Boolean }
+	union SearchResult = Dog | Cat
+	enum Color { RED GREEN }
+	input Filter { color: Color next: Filter }
+	`)
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	if s.Query == nil || s.Query.Name != "Root" {
+		t.Fatalf("Expected the query root to be Root, got %v", s.Query)
+	}
+	if f := s.Query.Fields.Get("search"); f == nil || f.Type.String() != "[SearchResult!]!" {
+		t.Errorf("Expected Root.search to be of type [SearchResult!]!, got %v", f)
+	}
+	pet := s.Type("Pet").(*schema.Interface)
+	if pet.Description != "a pet" || len(pet.PossibleTypes) != 2 {
+		t.Errorf("Expected Pet to be implemented by Dog and Cat, got %v", pet.PossibleTypes)
+	}
+	if !s.TypesOverlap(pet, s.Type("SearchResult")) || !s.TypesOverlap(s.Type("Dog"), pet) {
+		t.Errorf("Expected Pet to overlap with SearchResult and Dog")
+	}
+	if s.TypesOverlap(s.Type("Dog"), s.Type("Cat")) || s.TypesOverlap(s.Query, pet) {
+		t.Errorf("Expected Dog not to overlap with Cat, nor Root with Pet")
+	}
+	if pet2 := s.Query.Fields.Get("pet").Type; pet2 != schema.Type(pet) {
+		t.Errorf("Expected Root.pet to link to the Pet interface, got %v", pet2)
+	}
+	union := s.Type("SearchResult").(*schema.Union)
+	if len(union.Types) != 2 || union.Types[0] != s.Type("Dog") {
+		t.Errorf("Expected SearchResult to contain Dog and Cat, got %v", union.Types)
+	}
+	for _, name := range []string{"Int", "Float", "String", "Boolean", "ID"} {
+		if _, ok := s.Type(name).(*schema.Scalar); !ok {
+			t.Errorf("Expected the built-in scalar %s to be defined", name)
+		}
+	}
+	for _, name := range []string{"skip", "include", "deprecated", "specifiedBy"} {
+		if s.Directives[name] == nil {
+			t.Errorf("Expected the built-in directive @%s to be defined", name)
+		}
+	}
+}
+
+func TestBuildExtensions(t *testing.T) {
+	s, errs := build(t, `
+	type Query { a: Int }
+	extend type Query implements Node { b: Int }
+	interface Node { b: Int }
+	type Mut { c: Int }
+	extend schema { mutation: Mut }
+	extend schema { query: Query }
+	enum E { A }
+	extend enum E @deprecated { B }
+	union U = Query
+	extend union U = Mut
+	extend scalar String @specifiedBy(url: "x")
+	directive @tag(name: String) repeatable on OBJECT
+	extend type Query @tag(name: "a") @tag(name: "b")
+	`)
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	if f := s.Query.Fields; len(f) != 2 || f[1].Name != "b" {
+		t.Errorf("Expected Query to have the fields a and b, got %v", f)
+	}
+	if len(s.Query.Interfaces) != 1 || s.Query.Interfaces[0].Name != "Node" {
+		t.Errorf("Expected Query to implement Node, got %v", s.Query.Interfaces)
+	}
+	if s.Mutation == nil || s.Mutation.Name != "Mut" {
+		t.Errorf("Expected the mutation type to be Mut, got %v", s.Mutation)
+	}
+	if e := s.Type("E").(*schema.Enum); len(e.Values) != 2 || len(e.Directives) != 1 {
+		t.Errorf("Expected E to have two values and a directive, got %+v", e)
+	}
+	if u := s.Type("U").(*schema.Union); len(u.Types) != 2 {
+		t.Errorf("Expected U to include two types, got %v", u.Types)
+	}
+	if !s.Directives["tag"].Repeatable || s.Directives["deprecated"].Repeatable {
+		t.Errorf("Expected only @tag to be repeatable")
+	}
+	if url, ok := s.Type("String").(*schema.Scalar).SpecifiedByURL(); !ok || url != "x" {
+		t.Errorf("Expected String to be specified by x, got %q", url)
+	}
+
+	s, errs = build(t, `type Query { a: Int } type Mutation { m: Int } extend schema @deprecated`)
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+	if s.Query == nil || s.Query.Name != "Query" || s.Mutation == nil || s.Mutation.Name != "Mutation" {
+		t.Errorf("Expected the extended schema to keep the default root types, got %v and %v", s.Query, s.Mutation)
+	}
+}
+
+func TestBuildInterfaceHierarchy(t *testing.T) {
+	s, errs := build(t, `
+	type Query { node: Node }
+	interface Node { id: ID! }
+	interface Resource implements Node { id: ID! url: String }
+	type Image implements Resource & Node { id: ID! url: String thumbnail: Resource }
+	interface Named implements Node { id: ID! child: Node }
+	interface Person implements Named & Node { id: ID! child: Resource }
+	`)
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	resource := s.Type("Resource").(*schema.Interface)
+	if len(resource.Interfaces) != 1 || resource.Interfaces[0].Name != "Node" {
+		t.Errorf("Expected Resource to implement Node, got %v", resource.Interfaces)
+	}
+	if !schema.IsSubType(s, resource, s.Type("Node")) {
+		t.Errorf("Expected Resource to be a sub-type of Node")
+	}
+	if !s.IsPossibleType(s.Type("Node"), s.Type("Image").(*schema.Object)) {
+		t.Errorf("Expected Image to be a possible type of Node")
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := map[string]string{
+		`type Mutation { x: Int }`:                 "schema must define a query root type",
+		`type Query { x: Unknown }`:                "Query.x refers to unknown type Unknown",
+		`type Query { x(a: Query): Int }`:          "argument Query.x(a:) must be an input type",
+		`type Query { x: In } input In { y: Int }`: "field Query.x must be an output type",
+		`type Query { x: Int } interface I { y: Int } type T implements I { x: Int }`:                                     "field T.y is required by interface I",
+		`type Query { x: Int } interface I { y: Int } type T implements I { y: String }`:                                  "field T.y must be of type Int",
+		`type Query { x: Int } union U = Query | In input In { y: Int }`:                                                  "union U can only include object types",
+		`type Query { x: Int } input In { a: In! }`:                                                                       "input In cannot reference itself",
+		`type Query { x: Int } type String { y: Int }`:                                                                    "conflicts with the built-in scalar",
+		`type Query { __x: Int }`:                                                                                         "must not begin with \"__\"",
+		`type Query { x: Int } interface A { x: Int } interface B implements A { x: Int } type T implements B { x: Int }`: "type T must implement A because it is implemented by B",
+		`type Query { x: Int } interface A implements A { x: Int }`:                                                       "interface A cannot implement itself",
+		`type Query { x: Int } interface A { x: Int } interface B implements A { y: Int }`:                                "field B.x is required by interface A",
+		`type Query { x: Int @deprecated @deprecated }`:                                                                   "directive @deprecated can only be used once on Query.x",
+		`type Query { x: Int } extend type Missing { y: Int }`:                                                            "cannot extend type Missing because it is not defined",
+		`type Query { x: Int } extend input Query { y: Int }`:                                                             "cannot extend type Query using extend input",
+		`type Query { x: Int } extend type Query { x: String }`:                                                           "field Query.x can only be defined once",
+		`type Query { x: Int } enum E { A } extend enum E { A }`:                                                          "enum value E.A can only be defined once",
+		`schema { query: Query } type Query { x: Int } extend schema { query: Query }`:                                    "the schema already defines a query type",
+		`type Query { x: Int } type Query { y: Int }`:                                                                     "type Query can only be defined once",
+		`type Query { x: Int } directive @a on FIELD directive @a on QUERY`:                                               "directive @a can only be defined once",
+	}
+	for src, expected := range tests {
+		_, errs := build(t, src)
+		found := false
+		for _, err := range errs {
+			if strings.Contains(err.Error(), expected) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected Build(%s) to return an error containing %q, got %v", src, expected, errs)
+		}
+	}
+}
+
+func TestTypeInfo(t *testing.T) {
+	s, errs := build(t, `
+	type Query { pet: Pet dogs(filter: Filter, ids: [ID!]): [Dog!] }
+	interface Pet { name: String }
+	type Dog implements Pet { name: String barks: Boolean }
+	input Filter { names: [String] }
+	`)
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+	p := parser.Parser{}
+	p.Init([]byte(`
+	query Q($id: ID!) {
+		pet { name ... on Dog { barks } __typename }
+		dogs(filter: {names: ["a"]}, ids: [$id]) @include(if: true) { ...D missing }
+	}
+	fragment D on Dog { name }
+	`))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	ti := &schema.TypeInfo{Schema: s}
+	ast.Walk(ti.Visitor(ast.Visitor{
+		Enter: func(node interface{}, path []interface{}) bool {
+			str := func(t schema.Type) string {
+				if t == nil {
+					return "nil"
+				}
+				return t.String()
+			}
+			switch n := node.(type) {
+			case *ast.Field:
+				def := "nil"
+				if ti.FieldDef() != nil {
+					def = ti.FieldDef().Name
+				}
+				seen = append(seen, str(ti.ParentType())+"."+n.Name+": "+str(ti.Type())+" ("+def+")")
+			case *ast.InlineFragment, *ast.FragmentDef:
+				seen = append(seen, "fragment on "+str(ti.Type()))
+			case *ast.Argument:
+				seen = append(seen, n.Name+": "+str(ti.InputType()))
+			case *ast.Value:
+				seen = append(seen, "value: "+str(ti.InputType()))
+			case *ast.Directive:
+				seen = append(seen, "@"+ti.Directive().Name)
+			case *ast.VariableDef:
+				seen = append(seen, "$"+n.Name+": "+str(ti.InputType()))
+			}
+			return true
+		},
+	}), &doc)
+
+	expected := []string{
+		"$id: ID!",
+		"Query.pet: Pet (pet)",
+		"Pet.name: String (name)",
+		"fragment on Dog",
+		"Dog.barks: Boolean (barks)",
+		"Pet.__typename: String! (__typename)",
+		"Query.dogs: [Dog!] (dogs)",
+		"filter: Filter",
+		"value: Filter",
+		"names: [String]",
+		"value: [String]",
+		"value: String",
+		"ids: [ID!]",
+		"value: [ID!]",
+		"value: ID!",
+		"@include",
+		"if: Boolean!",
+		"value: Boolean!",
+		"Dog.missing: nil (nil)",
+		"fragment on Dog",
+		"Dog.name: String (name)",
+	}
+	if strings.Join(seen, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected the type info\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(seen, "\n"))
+	}
+	if ti.Type() != nil || ti.ParentType() != nil {
+		t.Errorf("Expected the type info to be empty after the walk")
+	}
+}
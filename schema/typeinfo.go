@@ -0,0 +1,174 @@
+package schema
+
+import "github.com/dianelooney/graphql/ast"
+
+// TypeInfo tracks the schema definitions that apply to the nodes of an
+// executable document while it is walked with ast.Walk
+//
+// Its methods describe the node entered last that has not been left yet,
+// and return nil for what is unknown, such as the definition of a field
+// that is not in the schema
+type TypeInfo struct {
+	Schema *Schema
+
+	frames []typeFrame
+}
+
+// typeFrame holds what TypeInfo knows about an entered node
+type typeFrame struct {
+	node       interface{}
+	parentType Type
+	typ        Type
+	fieldDef   *Field
+	directive  *Directive
+	argument   *InputValue
+	inputType  Type
+}
+
+// Visitor returns a visitor calling ti.Enter before v.Enter, and ti.Leave
+// after v.Leave, so that v can use ti
+func (ti *TypeInfo) Visitor(v ast.Visitor) ast.Visitor {
+	return ast.Visitor{
+		Enter: func(node interface{}, path []interface{}) bool {
+			ti.Enter(node)
+			if v.Enter != nil && !v.Enter(node, path) {
+				ti.Leave(node)
+				return false
+			}
+			return true
+		},
+		Leave: func(node interface{}, path []interface{}) {
+			if v.Leave != nil {
+				v.Leave(node, path)
+			}
+			ti.Leave(node)
+		},
+	}
+}
+
+// Enter updates ti for entering node, as Walk passes it
+func (ti *TypeInfo) Enter(node interface{}) {
+	top := ti.top()
+	f := top
+	f.node = node
+
+	switch n := node.(type) {
+	case *ast.Operation:
+		f.parentType = nil
+		f.typ = nil
+		if root := ti.Schema.RootType(n.OpType); root != nil {
+			f.typ = root
+		}
+	case *ast.FragmentDef:
+		f.parentType = nil
+		f.typ = ti.Schema.Type(n.Type)
+	case *ast.Field:
+		f.parentType = selectionParent(top.typ)
+		f.fieldDef = nil
+		f.typ = nil
+		if f.parentType != nil {
+			f.fieldDef = ti.Schema.FieldDef(f.parentType, n.Name)
+		}
+		if f.fieldDef != nil {
+			f.typ = f.fieldDef.Type
+		}
+	case *ast.InlineFragment:
+		f.parentType = selectionParent(top.typ)
+		f.typ = f.parentType
+		if n.Type != nil {
+			f.typ = ti.Schema.Type(*n.Type)
+		}
+	case *ast.FragmentSpread:
+		f.parentType = selectionParent(top.typ)
+		f.typ = nil
+	case *ast.VariableDef:
+		f.inputType = ti.Schema.TypeFromAST(n.Type)
+	case *ast.Directive:
+		f.directive = ti.Schema.Directives[n.Name]
+	case *ast.Argument:
+		f.argument = nil
+		f.inputType = nil
+		switch top.node.(type) {
+		case *ast.Directive:
+			if top.directive != nil {
+				f.argument = top.directive.Args.Get(n.Name)
+			}
+		case *ast.Field:
+			if top.fieldDef != nil {
+				f.argument = top.fieldDef.Args.Get(n.Name)
+			}
+		case *ast.Value:
+			if obj, ok := NamedType(top.inputType).(*InputObject); ok {
+				f.argument = obj.Fields.Get(n.Name)
+			}
+		}
+		if f.argument != nil {
+			f.inputType = f.argument.Type
+		}
+	case *ast.Value:
+		if _, ok := top.node.(*ast.Value); ok {
+			f.inputType = nil
+			if list, ok := Nullable(top.inputType).(*List); ok {
+				f.inputType = list.OfType
+			}
+		}
+	}
+
+	ti.frames = append(ti.frames, f)
+}
+
+// Leave updates ti for leaving node
+func (ti *TypeInfo) Leave(node interface{}) {
+	if len(ti.frames) > 0 {
+		ti.frames = ti.frames[:len(ti.frames)-1]
+	}
+}
+
+func (ti *TypeInfo) top() typeFrame {
+	if len(ti.frames) == 0 {
+		return typeFrame{}
+	}
+	return ti.frames[len(ti.frames)-1]
+}
+
+// selectionParent returns the composite type whose fields are selected
+// within a node of type t
+func selectionParent(t Type) Type {
+	if t = NamedType(t); IsCompositeType(t) {
+		return t
+	}
+	return nil
+}
+
+// ParentType returns the composite type the current field, fragment spread
+// or inline fragment is selected on
+func (ti *TypeInfo) ParentType() Type {
+	return ti.top().parentType
+}
+
+// Type returns the output type of the current operation, field or fragment
+func (ti *TypeInfo) Type() Type {
+	return ti.top().typ
+}
+
+// FieldDef returns the definition of the current field
+func (ti *TypeInfo) FieldDef() *Field {
+	return ti.top().fieldDef
+}
+
+// Directive returns the definition of the current directive
+func (ti *TypeInfo) Directive() *Directive {
+	return ti.top().directive
+}
+
+// Argument returns the definition of the current argument or input object
+// field
+func (ti *TypeInfo) Argument() *InputValue {
+	return ti.top().argument
+}
+
+// InputType returns the type expected of the current value or variable
+// definition
+func (ti *TypeInfo) InputType() Type {
+	return ti.top().inputType
+}
@@ -0,0 +1,261 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/dianelooney/graphql/ast"
+)
+
+// validate checks the rules of the spec's Type System section
+// that are not already enforced while linking the types
+func (b *builder) validate() {
+	if b.s.Query == nil {
+		b.errorf("schema must define a query root type")
+	}
+
+	for _, name := range ast.SortedNames(b.types) {
+		switch t := b.s.Types[name].(type) {
+		case *Object:
+			b.validateFields(name, t.Fields)
+			b.validateInterfaces(name, t.Fields, t.Interfaces)
+		case *Interface:
+			b.validateFields(name, t.Fields)
+			b.validateInterfaces(name, t.Fields, t.Interfaces)
+		case *Union:
+			if len(t.Types) == 0 && len(b.types[name].UnionDef.Types) == 0 {
+				b.errorf("union %s must include one or more member types", name)
+			}
+		case *Enum:
+			b.validateEnum(t)
+		case *InputObject:
+			b.validateInputObject(t)
+		}
+		b.validateTypeDirectives(b.s.Types[name])
+	}
+
+	for _, name := range ast.SortedNames(b.directives) {
+		d := b.s.Directives[name]
+		b.validateArgs("@"+name, d.Args)
+		if len(d.Locations) == 0 {
+			b.errorf("directive @%s must have one or more locations", name)
+		}
+	}
+}
+
+// validateTypeDirectives checks the directives applied to t and to its
+// fields, arguments and values
+func (b *builder) validateTypeDirectives(t Type) {
+	switch t := t.(type) {
+	case *Scalar:
+		b.validateUniqueDirectives(t.Name, t.Directives)
+	case *Object:
+		b.validateUniqueDirectives(t.Name, t.Directives)
+		b.validateFieldDirectives(t.Name, t.Fields)
+	case *Interface:
+		b.validateUniqueDirectives(t.Name, t.Directives)
+		b.validateFieldDirectives(t.Name, t.Fields)
+	case *Union:
+		b.validateUniqueDirectives(t.Name, t.Directives)
+	case *Enum:
+		b.validateUniqueDirectives(t.Name, t.Directives)
+		for _, v := range t.Values {
+			b.validateUniqueDirectives(t.Name+"."+v.Name, v.Directives)
+		}
+	case *InputObject:
+		b.validateUniqueDirectives(t.Name, t.Directives)
+		for _, f := range t.Fields {
+			b.validateUniqueDirectives(t.Name+"."+f.Name, f.Directives)
+		}
+	}
+}
+
+func (b *builder) validateFieldDirectives(parent string, fields FieldList) {
+	for _, f := range fields {
+		coord := parent + "." + f.Name
+		b.validateUniqueDirectives(coord, f.Directives)
+		for _, arg := range f.Args {
+			b.validateUniqueDirectives(coord+"("+arg.Name+":)", arg.Directives)
+		}
+	}
+}
+
+// validateUniqueDirectives implements "Directives Are Unique Per Location"
+// for the directives applied at coord
+func (b *builder) validateUniqueDirectives(coord string, directives []ast.Directive) {
+	seen := make(map[string]bool)
+	for _, d := range directives {
+		if dir := b.s.Directives[d.Name]; seen[d.Name] && dir != nil && !dir.Repeatable {
+			b.errorf("directive @%s can only be used once on %s", d.Name, coord)
+		}
+		seen[d.Name] = true
+	}
+}
+
+func (b *builder) validateFields(parent string, fields FieldList) {
+	if len(fields) == 0 {
+		b.errorf("type %s must define one or more fields", parent)
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		coord := parent + "." + f.Name
+		if seen[f.Name] {
+			b.errorf("field %s can only be defined once", coord)
+		}
+		seen[f.Name] = true
+		if strings.HasPrefix(f.Name, "__") {
+			b.errorf("field %s must not begin with \"__\", which is reserved for introspection", coord)
+		}
+		if f.Type != nil && !IsOutputType(f.Type) {
+			b.errorf("field %s must be an output type, it cannot be %s", coord, f.Type)
+		}
+		b.validateArgs(coord, f.Args)
+	}
+}
+
+func (b *builder) validateArgs(parent string, args InputValueList) {
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		coord := parent + "(" + arg.Name + ":)"
+		if seen[arg.Name] {
+			b.errorf("argument %s can only be defined once", coord)
+		}
+		seen[arg.Name] = true
+		if strings.HasPrefix(arg.Name, "__") {
+			b.errorf("argument %s must not begin with \"__\", which is reserved for introspection", coord)
+		}
+		if arg.Type != nil && !IsInputType(arg.Type) {
+			b.errorf("argument %s must be an input type, it cannot be %s", coord, arg.Type)
+		}
+	}
+}
+
+// validateInterfaces checks that a type implementing an interface also
+// implements the interfaces it implements, and satisfies all of them
+func (b *builder) validateInterfaces(parent string, fields FieldList, interfaces []*Interface) {
+	for _, intf := range interfaces {
+		for _, transitive := range intf.Interfaces {
+			if !implements(interfaces, transitive) {
+				b.errorf("type %s must implement %s because it is implemented by %s", parent, transitive.Name, intf.Name)
+			}
+		}
+		b.validateImplementation(parent, fields, intf)
+	}
+}
+
+func implements(interfaces []*Interface, intf *Interface) bool {
+	for _, i := range interfaces {
+		if i == intf {
+			return true
+		}
+	}
+	return false
+}
+
+// validateImplementation checks that fields satisfy every field of intf
+func (b *builder) validateImplementation(parent string, fields FieldList, intf *Interface) {
+	for _, intfField := range intf.Fields {
+		coord := parent + "." + intfField.Name
+		f := fields.Get(intfField.Name)
+		if f == nil {
+			b.errorf("field %s is required by interface %s but is not defined", coord, intf.Name)
+			continue
+		}
+		if f.Type == nil || intfField.Type == nil {
+			continue
+		}
+		if !IsSubType(b.s, f.Type, intfField.Type) {
+			b.errorf("field %s must be of type %s or a sub-type of it to implement interface %s, but is %s",
+				coord, intfField.Type, intf.Name, f.Type)
+		}
+
+		for _, intfArg := range intfField.Args {
+			arg := f.Args.Get(intfArg.Name)
+			if arg == nil {
+				b.errorf("argument %s(%s:) is required by interface %s but is not defined", coord, intfArg.Name, intf.Name)
+				continue
+			}
+			if arg.Type != nil && intfArg.Type != nil && !IsEqualType(arg.Type, intfArg.Type) {
+				b.errorf("argument %s(%s:) must be of type %s to implement interface %s, but is %s",
+					coord, arg.Name, intfArg.Type, intf.Name, arg.Type)
+			}
+		}
+		for _, arg := range f.Args {
+			if intfField.Args.Get(arg.Name) != nil {
+				continue
+			}
+			if _, ok := arg.Type.(*NonNull); ok && arg.DefaultValue == nil {
+				b.errorf("argument %s(%s:) is not defined by interface %s and cannot be required", coord, arg.Name, intf.Name)
+			}
+		}
+	}
+}
+
+func (b *builder) validateEnum(t *Enum) {
+	if len(t.Values) == 0 {
+		b.errorf("enum %s must define one or more values", t.Name)
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range t.Values {
+		if seen[v.Name] {
+			b.errorf("enum value %s.%s can only be defined once", t.Name, v.Name)
+		}
+		seen[v.Name] = true
+		if v.Name == "true" || v.Name == "false" || v.Name == "null" {
+			b.errorf("enum value %s.%s is not allowed", t.Name, v.Name)
+		}
+	}
+}
+
+func (b *builder) validateInputObject(t *InputObject) {
+	if len(t.Fields) == 0 {
+		b.errorf("input %s must define one or more fields", t.Name)
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range t.Fields {
+		coord := t.Name + "." + f.Name
+		if seen[f.Name] {
+			b.errorf("input field %s can only be defined once", coord)
+		}
+		seen[f.Name] = true
+		if strings.HasPrefix(f.Name, "__") {
+			b.errorf("input field %s must not begin with \"__\", which is reserved for introspection", coord)
+		}
+		if f.Type != nil && !IsInputType(f.Type) {
+			b.errorf("input field %s must be an input type, it cannot be %s", coord, f.Type)
+		}
+	}
+
+	if path := nonNullCycle(t, []string{t.Name}, make(map[*InputObject]bool)); path != nil {
+		b.errorf("input %s cannot reference itself through non-null fields: %s", t.Name, strings.Join(path, "."))
+	}
+}
+
+// nonNullCycle looks for a chain of non-null, non-list fields leading from t back to
+// the input object the search started at, which no finite value could satisfy
+func nonNullCycle(t *InputObject, path []string, visited map[*InputObject]bool) []string {
+	visited[t] = true
+	for _, f := range t.Fields {
+		nn, ok := f.Type.(*NonNull)
+		if !ok {
+			continue
+		}
+		next, ok := nn.OfType.(*InputObject)
+		if !ok {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], f.Name)
+		if next.Name == path[0] {
+			return fieldPath
+		}
+		if visited[next] {
+			continue
+		}
+		if cycle := nonNullCycle(next, fieldPath, visited); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
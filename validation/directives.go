@@ -0,0 +1,30 @@
+package validation
+
+import (
+	"github.com/dianelooney/graphql/ast"
+)
+
+// validateDirectives implements "Directives Are Defined", "Directives Are In Valid Locations"
+// and "Directives Are Unique Per Location" for the directives used at location
+func (v *validator) validateDirectives(def string, directives []ast.Directive, location string) {
+	seen := make(map[string]bool)
+	for _, d := range directives {
+		dir := v.s.Directives[d.Name]
+		if dir == nil {
+			v.errorf(d.Node, "unknown directive \"@%s\"", d.Name)
+			for _, arg := range d.Arguments {
+				v.validateValue(def, arg.Value, nil, false)
+			}
+			continue
+		}
+		if !dir.HasLocation(location) {
+			v.errorf(d.Node, "directive \"@%s\" may not be used on %s", d.Name, location)
+		}
+		if seen[d.Name] && !dir.Repeatable {
+			v.errorf(d.Node, "directive \"@%s\" can only be used once at this location", d.Name)
+		}
+		seen[d.Name] = true
+
+		v.validateArguments(def, d.Node, "directive \"@"+d.Name+"\"", dir.Args, d.Arguments)
+	}
+}
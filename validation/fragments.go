@@ -0,0 +1,168 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// validateFragments implements "Fragment Name Uniqueness", "Fragment Spread
+// Type Existence", "Fragments On Composite Types", "Fragments Must Be Used"
+// and "Fragment Spreads Must Not Form Cycles" for fragment definitions, and
+// walks every fragment definition
+func (v *validator) validateFragments() {
+	seen := make(map[string]bool)
+	for _, d := range v.doc.Definitions {
+		if d.Fragment == nil {
+			continue
+		}
+		if seen[d.Fragment.Name] {
+			v.errorf(d.Fragment.Node, "there can be only one fragment named \"%s\"", d.Fragment.Name)
+		}
+		seen[d.Fragment.Name] = true
+	}
+
+	for _, name := range v.fragmentNames() {
+		frag := v.doc.Fragments[name]
+		def := fragmentKey(name)
+		v.validateDirectives(def, frag.Directives, "FRAGMENT_DEFINITION")
+
+		t := v.typeCondition(frag.Node, "fragment \""+name+"\"", frag.Type)
+		if t == nil {
+			continue
+		}
+		v.validateSelectionSet(def, t, frag.SelectionSet)
+	}
+
+	used := make(map[string]bool)
+	for _, op := range v.operations() {
+		v.reachableFragments(operationKey(op), used)
+	}
+	for _, name := range v.fragmentNames() {
+		if !used[name] {
+			v.errorf(v.doc.Fragments[name].Node, "fragment \"%s\" is never used", name)
+		}
+	}
+
+	v.detectCycles()
+}
+
+// typeCondition resolves the type condition of the fragment n, which must be
+// a composite type
+func (v *validator) typeCondition(n ast.Node, what string, name string) schema.Type {
+	t := v.s.Type(name)
+	if t == nil {
+		v.errorf(n, "%s cannot condition on unknown type \"%s\"", what, name)
+		return nil
+	}
+	if !schema.IsCompositeType(t) {
+		v.errorf(n, "%s cannot condition on non composite type \"%s\"", what, name)
+		return nil
+	}
+	return t
+}
+
+// reachableFragments adds every fragment spread from def, directly or indirectly, to used
+func (v *validator) reachableFragments(def string, used map[string]bool) {
+	for _, spread := range v.spreads[def] {
+		if used[spread.Name] {
+			continue
+		}
+		used[spread.Name] = true
+		v.reachableFragments(fragmentKey(spread.Name), used)
+	}
+}
+
+// detectCycles reports the cycles of fragment spreads found by a depth first
+// search, which visits every fragment once
+func (v *validator) detectCycles() {
+	visited := make(map[string]bool)
+	reported := make(map[string]bool)
+	// index holds the position in path of the fragments being visited
+	index := make(map[string]int)
+	var path []*ast.FragmentSpread
+
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		index[name] = len(path)
+		for _, spread := range v.spreads[fragmentKey(name)] {
+			next := spread.Name
+			path = append(path, spread)
+			if i, ok := index[next]; ok {
+				cycle := []string{next}
+				var nodes []ast.Node
+				for _, s := range path[i:] {
+					cycle = append(cycle, s.Name)
+					nodes = append(nodes, s.Node)
+				}
+				if key := cycleKey(cycle); !reported[key] {
+					reported[key] = true
+					v.errors = append(v.errors, gqlerror.ErrorfAt(locations(nodes...), "cannot spread fragment \"%s\" within itself via %s", next, strings.Join(cycle[1:], ", ")))
+				}
+			} else if _, ok := v.doc.Fragments[next]; ok {
+				visit(next)
+			}
+			path = path[:len(path)-1]
+		}
+		delete(index, name)
+	}
+
+	for _, name := range v.fragmentNames() {
+		visit(name)
+	}
+}
+
+// cycleKey identifies a cycle regardless of the fragment it was found from
+func cycleKey(cycle []string) string {
+	members := cycle[:len(cycle)-1]
+	start := 0
+	for i, m := range members {
+		if m < members[start] {
+			start = i
+		}
+	}
+	return strings.Join(append(members[start:len(members):len(members)], members[:start]...), ",")
+}
+
+// validateFragmentSpread implements "Fragment Spread Target Defined" and "Fragment Spread Is Possible"
+func (v *validator) validateFragmentSpread(def string, parent schema.Type, spread *ast.FragmentSpread) {
+	v.validateDirectives(def, spread.Directives, "FRAGMENT_SPREAD")
+	v.spreads[def] = append(v.spreads[def], spread)
+
+	frag, ok := v.doc.Fragments[spread.Name]
+	if !ok {
+		v.errorf(spread.Node, "unknown fragment \"%s\"", spread.Name)
+		return
+	}
+	t := v.s.Type(frag.Type)
+	if t == nil || !schema.IsCompositeType(t) {
+		return
+	}
+	if !v.s.TypesOverlap(parent, t) {
+		v.errorf(spread.Node, "fragment \"%s\" cannot be spread here as objects of type \"%s\" can never be of type \"%s\"", spread.Name, parent, t)
+	}
+}
+
+// validateInlineFragment implements "Fragment Spread Type Existence",
+// "Fragments On Composite Types" and "Fragment Spread Is Possible" for inline fragments
+func (v *validator) validateInlineFragment(def string, parent schema.Type, frag *ast.InlineFragment) {
+	v.validateDirectives(def, frag.Directives, "INLINE_FRAGMENT")
+
+	t := parent
+	if frag.Type != nil {
+		t = v.typeCondition(frag.Node, "inline fragment", *frag.Type)
+		if t == nil {
+			return
+		}
+		if !v.s.TypesOverlap(parent, t) {
+			v.errorf(frag.Node, "inline fragment cannot be spread here as objects of type \"%s\" can never be of type \"%s\"", parent, t)
+		}
+	}
+	v.validateSelectionSet(def, t, frag.SelectionSet)
+}
@@ -0,0 +1,250 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/printer"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// fieldAndParent is a selected field together with the type it was selected on
+type fieldAndParent struct {
+	parent schema.Type
+	field  *ast.Field
+	def    *schema.Field
+}
+
+// collectFields groups the fields selected by sel on parent by response key,
+// following fragment spreads and inline fragments
+func (v *validator) collectFields(parent schema.Type, sel []ast.Selection, fields map[string][]fieldAndParent, keys *[]string, visited map[string]bool) {
+	for _, s := range sel {
+		switch {
+		case s.Field != nil:
+			key := s.Field.Name
+			if s.Field.Alias != nil {
+				key = *s.Field.Alias
+			}
+			if _, ok := fields[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			fields[key] = append(fields[key], fieldAndParent{parent, s.Field, v.s.FieldDef(parent, s.Field.Name)})
+		case s.FragmentSpread != nil:
+			if visited[s.FragmentSpread.Name] {
+				continue
+			}
+			visited[s.FragmentSpread.Name] = true
+			frag, ok := v.doc.Fragments[s.FragmentSpread.Name]
+			if !ok {
+				continue
+			}
+			t := v.s.Type(frag.Type)
+			if t == nil {
+				continue
+			}
+			v.collectFields(t, frag.SelectionSet, fields, keys, visited)
+		case s.InlineFragment != nil:
+			t := parent
+			if s.InlineFragment.Type != nil {
+				t = v.s.Type(*s.InlineFragment.Type)
+				if t == nil {
+					continue
+				}
+			}
+			v.collectFields(t, s.InlineFragment.SelectionSet, fields, keys, visited)
+		}
+	}
+}
+
+// validateFieldsCanMerge implements "Field Selection Merging"
+//
+// Fields sharing a response key within sel are compared pairwise, once per
+// distinct field. Conflicts between subfields of the same field are found
+// when its own selection set is validated
+func (v *validator) validateFieldsCanMerge(parent schema.Type, sel []ast.Selection) {
+	fields := make(map[string][]fieldAndParent)
+	var keys []string
+	v.collectFields(parent, sel, fields, &keys, make(map[string]bool))
+
+	for _, key := range keys {
+		group := v.distinctFields(fields[key])
+		for i := 0; i < len(group); i++ {
+			for j := i + 1; j < len(group); j++ {
+				reason := v.findConflict(group[i], group[j], false)
+				if reason == "" {
+					continue
+				}
+				msg := fmt.Sprintf("fields \"%s\" conflict because %s, use different aliases on the fields to fetch both if this was intentional", key, reason)
+				if !v.conflicts[msg] {
+					v.conflicts[msg] = true
+					v.errors = append(v.errors, gqlerror.ErrorfAt(locations(group[i].field.Node, group[j].field.Node), "%s", msg))
+				}
+			}
+		}
+	}
+}
+
+// distinctFields drops the fields of group that are printed the same as an
+// earlier one selected on the same type. They merge with each other and
+// conflict with the same fields, and comparing every copy of a field
+// repeated many times would take quadratic time
+func (v *validator) distinctFields(group []fieldAndParent) []fieldAndParent {
+	if len(group) < 2 {
+		return group
+	}
+	type shape struct {
+		parent schema.Type
+		text   string
+	}
+	seen := make(map[shape]bool, len(group))
+	out := group[:0:0]
+	for _, f := range group {
+		text, ok := v.printed[f.field]
+		if !ok {
+			text = printer.Sprint(f.field)
+			v.printed[f.field] = text
+		}
+		if s := (shape{f.parent, text}); !seen[s] {
+			seen[s] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// conflictKey identifies a comparison of two fields by findConflict
+type conflictKey struct {
+	a, b             *ast.Field
+	aParent, bParent schema.Type
+	exclusive        bool
+}
+
+// findConflict returns why a and b cannot be merged, or "" if they can
+//
+// exclusive is set when a and b can never be selected on the same object,
+// in which case they only need to have the same response shape
+//
+// The result of comparing each pair of fields is remembered, as fragments
+// spread more than once make the same pairs come up again and again
+func (v *validator) findConflict(a, b fieldAndParent, exclusive bool) string {
+	if a.field == b.field && a.parent == b.parent {
+		return ""
+	}
+	key := conflictKey{a.field, b.field, a.parent, b.parent, exclusive}
+	if reason, ok := v.compared[key]; ok {
+		return reason
+	}
+	reason := v.compareFields(a, b, exclusive)
+	v.compared[key] = reason
+	return reason
+}
+
+// compareFields does the work of findConflict
+func (v *validator) compareFields(a, b fieldAndParent, exclusive bool) string {
+	if !exclusive && a.parent != b.parent {
+		_, aObj := a.parent.(*schema.Object)
+		_, bObj := b.parent.(*schema.Object)
+		exclusive = aObj && bObj
+	}
+
+	if !exclusive {
+		if a.field.Name != b.field.Name {
+			return fmt.Sprintf("\"%s\" and \"%s\" are different fields", a.field.Name, b.field.Name)
+		}
+		if !sameArguments(a.field.Arguments, b.field.Arguments) {
+			return "they have differing arguments"
+		}
+	}
+
+	if a.def != nil && b.def != nil && typesConflict(a.def.Type, b.def.Type) {
+		return fmt.Sprintf("they return conflicting types \"%s\" and \"%s\"", a.def.Type, b.def.Type)
+	}
+
+	if len(a.field.SelectionSet) == 0 || len(b.field.SelectionSet) == 0 || a.def == nil || b.def == nil {
+		return ""
+	}
+
+	aFields := make(map[string][]fieldAndParent)
+	bFields := make(map[string][]fieldAndParent)
+	var aKeys, bKeys []string
+	v.collectFields(schema.NamedType(a.def.Type), a.field.SelectionSet, aFields, &aKeys, make(map[string]bool))
+	v.collectFields(schema.NamedType(b.def.Type), b.field.SelectionSet, bFields, &bKeys, make(map[string]bool))
+	for _, key := range aKeys {
+		for _, x := range v.distinctFields(aFields[key]) {
+			for _, y := range v.distinctFields(bFields[key]) {
+				if reason := v.findConflict(x, y, exclusive); reason != "" {
+					return fmt.Sprintf("subfields \"%s\" conflict because %s", key, reason)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// typesConflict reports whether two fields of types a and b could produce
+// differently shaped responses
+func typesConflict(a, b schema.Type) bool {
+	if l, ok := a.(*schema.List); ok {
+		bl, ok := b.(*schema.List)
+		return !ok || typesConflict(l.OfType, bl.OfType)
+	}
+	if _, ok := b.(*schema.List); ok {
+		return true
+	}
+	if nn, ok := a.(*schema.NonNull); ok {
+		bnn, ok := b.(*schema.NonNull)
+		return !ok || typesConflict(nn.OfType, bnn.OfType)
+	}
+	if _, ok := b.(*schema.NonNull); ok {
+		return true
+	}
+	if schema.IsLeafType(a) || schema.IsLeafType(b) {
+		return a != b
+	}
+	return false
+}
+
+func sameArguments(a, b ast.Arguments) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, arg := range a {
+		other, ok := b.Get(arg.Name)
+		if !ok || !sameValue(arg.Value, other) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameValue(a, b ast.Value) bool {
+	switch {
+	case a.Variable != nil:
+		return b.Variable != nil && *a.Variable == *b.Variable
+	case a.Int != nil:
+		return b.Int != nil && *a.Int == *b.Int
+	case a.Float != nil:
+		return b.Float != nil && *a.Float == *b.Float
+	case a.String != nil:
+		return b.String != nil && *a.String == *b.String
+	case a.Bool != nil:
+		return b.Bool != nil && *a.Bool == *b.Bool
+	case a.Enum != nil:
+		return b.Enum != nil && *a.Enum == *b.Enum
+	case a.List != nil:
+		if b.List == nil || len(a.List) != len(b.List) {
+			return false
+		}
+		for i := range a.List {
+			if !sameValue(a.List[i], b.List[i]) {
+				return false
+			}
+		}
+		return true
+	case a.Object != nil:
+		return b.Object != nil && sameArguments(a.Object, b.Object)
+	}
+	return a.IsNull == b.IsNull && b.Variable == nil && b.Int == nil && b.Float == nil &&
+		b.String == nil && b.Bool == nil && b.Enum == nil && b.List == nil && b.Object == nil
+}
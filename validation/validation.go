@@ -0,0 +1,315 @@
+package validation
+
+import (
+	"sort"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// Validate checks an executable document against s,
+// using the rules of the spec's Validation section
+//
+// A document that returns no errors can be executed safely
+func Validate(s *schema.Schema, doc ast.Document) []error {
+	v := validator{
+		s:         s,
+		doc:       doc,
+		usages:    make(map[string][]variableUsage),
+		spreads:   make(map[string][]*ast.FragmentSpread),
+		conflicts: make(map[string]bool),
+		compared:  make(map[conflictKey]string),
+		printed:   make(map[*ast.Field]string),
+	}
+
+	v.validateExecutableDefinitions()
+	v.validateOperations()
+	v.validateFragments()
+	v.validateVariables()
+
+	return v.errors
+}
+
+type validator struct {
+	s      *schema.Schema
+	doc    ast.Document
+	errors []error
+
+	// usages and spreads are keyed by operationKey or fragmentKey
+	usages  map[string][]variableUsage
+	spreads map[string][]*ast.FragmentSpread
+
+	// conflicts holds the field merge conflicts already reported
+	conflicts map[string]bool
+
+	// compared holds the results of findConflict, and printed the fields
+	// printed by distinctFields
+	compared map[conflictKey]string
+	printed  map[*ast.Field]string
+}
+
+// errorf records an error pointing at n
+func (v *validator) errorf(n ast.Node, format string, args ...interface{}) {
+	v.errors = append(v.errors, gqlerror.ErrorfAt(locations(n), format, args...))
+}
+
+// locations returns the locations of the nodes that have a position
+func locations(nodes ...ast.Node) (locs []gqlerror.Location) {
+	for _, n := range nodes {
+		if n.Start.Line > 0 {
+			locs = append(locs, gqlerror.Location{Line: n.Start.Line, Column: n.Start.UTF16Column})
+		}
+	}
+	return locs
+}
+
+func operationKey(op *ast.Operation) string {
+	if op.Name == nil {
+		return "query:"
+	}
+	return "query:" + *op.Name
+}
+func fragmentKey(name string) string {
+	return "fragment:" + name
+}
+
+// operations returns the operations of the document, anonymous operation first
+func (v *validator) operations() (ops []*ast.Operation) {
+	if v.doc.Operation != nil {
+		ops = append(ops, v.doc.Operation)
+	}
+	names := make([]string, 0, len(v.doc.Operations))
+	for name := range v.doc.Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		op := v.doc.Operations[name]
+		ops = append(ops, &op)
+	}
+	return
+}
+
+func (v *validator) fragmentNames() []string {
+	names := make([]string, 0, len(v.doc.Fragments))
+	for name := range v.doc.Fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateExecutableDefinitions implements "Executable Definitions"
+func (v *validator) validateExecutableDefinitions() {
+	if v.doc.Schema != nil {
+		v.errorf(v.doc.Schema.Node, "the schema definition is not executable")
+	}
+	names := make([]string, 0, len(v.doc.Types))
+	for name := range v.doc.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v.errorf(v.doc.Types[name].Node(), "the %s definition is not executable", name)
+	}
+	names = names[:0]
+	for name := range v.doc.Directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v.errorf(v.doc.Directives[name].Node, "the @%s definition is not executable", name)
+	}
+	if len(v.doc.SchemaExtensions) > 0 {
+		v.errorf(v.doc.SchemaExtensions[0].Node, "the schema extension is not executable")
+	}
+	for _, ext := range v.doc.TypeExtensions {
+		v.errorf(ext.Node, "the %s extension is not executable", ext.Name())
+	}
+}
+
+// validateOperations implements "Operation Name Uniqueness", "Lone Anonymous
+// Operation" and "Subscription Operation Definitions", and walks every operation
+func (v *validator) validateOperations() {
+	ops := v.operations()
+	if len(ops) == 0 {
+		v.errorf(ast.Node{}, "document does not contain any operations")
+	}
+
+	seen := make(map[string]bool)
+	anonymous := 0
+	var anonymousNode ast.Node
+	for _, d := range v.doc.Definitions {
+		if d.Operation == nil {
+			continue
+		}
+		if d.Operation.Name == nil {
+			anonymous++
+			anonymousNode = d.Operation.Node
+			continue
+		}
+		if seen[*d.Operation.Name] {
+			v.errorf(d.Operation.Node, "there can be only one operation named \"%s\"", *d.Operation.Name)
+		}
+		seen[*d.Operation.Name] = true
+	}
+	if anonymous == 0 && v.doc.Operation != nil {
+		anonymousNode = v.doc.Operation.Node
+	}
+	if anonymous > 1 || v.doc.Operation != nil && len(v.doc.Operations) > 0 {
+		v.errorf(anonymousNode, "an anonymous operation must be the only defined operation")
+	}
+
+	for _, op := range ops {
+		def := operationKey(op)
+		v.validateVariableDefinitions(op)
+		v.validateDirectives(def, op.Directives, operationLocations[op.OpType])
+
+		root := v.s.RootType(op.OpType)
+		if root == nil {
+			v.errorf(op.Node, "schema does not support %s operations", op.OpType)
+			continue
+		}
+		if op.OpType == "subscription" {
+			v.validateSubscriptionRoot(op, root)
+		}
+		v.validateSelectionSet(def, root, op.SelectionSet)
+	}
+}
+
+var operationLocations = map[string]string{
+	"query":        "QUERY",
+	"mutation":     "MUTATION",
+	"subscription": "SUBSCRIPTION",
+}
+
+func (v *validator) validateSubscriptionRoot(op *ast.Operation, root *schema.Object) {
+	fields := make(map[string][]fieldAndParent)
+	var keys []string
+	v.collectFields(root, op.SelectionSet, fields, &keys, make(map[string]bool))
+
+	name := "anonymous subscription"
+	if op.Name != nil {
+		name = "subscription " + *op.Name
+	}
+	if len(keys) != 1 {
+		v.errorf(op.Node, "%s must select only one top level field", name)
+	}
+	for _, key := range keys {
+		if f := fields[key][0].field; f.Name == "__typename" || f.Name == "__schema" || f.Name == "__type" {
+			v.errorf(f.Node, "%s must not select the introspection field %s as a top level field", name, f.Name)
+		}
+	}
+	walkSelections(op.SelectionSet, v.doc.Fragments, func(directives []ast.Directive) {
+		for _, d := range directives {
+			if d.Name == "skip" || d.Name == "include" {
+				v.errorf(d.Node, "%s must not use @%s on its top level selections", name, d.Name)
+			}
+		}
+	})
+}
+
+// walkSelections calls fn with the directives of every selection in sel,
+// following fragment spreads but not descending into subfields
+func walkSelections(sel []ast.Selection, fragments map[string]ast.FragmentDef, fn func([]ast.Directive)) {
+	visited := make(map[string]bool)
+	var walk func([]ast.Selection)
+	walk = func(sel []ast.Selection) {
+		for _, s := range sel {
+			switch {
+			case s.Field != nil:
+				fn(s.Field.Directives)
+			case s.FragmentSpread != nil:
+				fn(s.FragmentSpread.Directives)
+				if visited[s.FragmentSpread.Name] {
+					continue
+				}
+				visited[s.FragmentSpread.Name] = true
+				if frag, ok := fragments[s.FragmentSpread.Name]; ok {
+					walk(frag.SelectionSet)
+				}
+			case s.InlineFragment != nil:
+				fn(s.InlineFragment.Directives)
+				walk(s.InlineFragment.SelectionSet)
+			}
+		}
+	}
+	walk(sel)
+}
+
+// validateSelectionSet checks every selection of sel, which is selected on parent
+func (v *validator) validateSelectionSet(def string, parent schema.Type, sel []ast.Selection) {
+	v.validateFieldsCanMerge(parent, sel)
+
+	for _, s := range sel {
+		switch {
+		case s.Field != nil:
+			v.validateField(def, parent, s.Field)
+		case s.FragmentSpread != nil:
+			v.validateFragmentSpread(def, parent, s.FragmentSpread)
+		case s.InlineFragment != nil:
+			v.validateInlineFragment(def, parent, s.InlineFragment)
+		}
+	}
+}
+
+// validateField implements "Field Selections" and "Leaf Field Selections",
+// and checks the arguments of field
+func (v *validator) validateField(def string, parent schema.Type, field *ast.Field) {
+	v.validateDirectives(def, field.Directives, "FIELD")
+
+	f := v.s.FieldDef(parent, field.Name)
+	if f == nil {
+		v.errorf(field.Node, "cannot query field \"%s\" on type \"%s\"", field.Name, parent)
+		for _, arg := range field.Arguments {
+			v.validateValue(def, arg.Value, nil, false)
+		}
+		return
+	}
+
+	v.validateArguments(def, field.Node, "field \""+parent.String()+"."+field.Name+"\"", f.Args, field.Arguments)
+
+	named := schema.NamedType(f.Type)
+	if schema.IsLeafType(named) {
+		if len(field.SelectionSet) > 0 {
+			v.errorf(field.Node, "field \"%s\" must not have a selection since type \"%s\" has no subfields", field.Name, f.Type)
+		}
+		return
+	}
+	if len(field.SelectionSet) == 0 {
+		v.errorf(field.Node, "field \"%s\" of type \"%s\" must have a selection of subfields", field.Name, f.Type)
+		return
+	}
+	v.validateSelectionSet(def, named, field.SelectionSet)
+}
+
+// validateArguments implements "Argument Names", "Argument Uniqueness" and
+// "Required Arguments" for the arguments given to coord, used at n
+func (v *validator) validateArguments(def string, n ast.Node, coord string, defs schema.InputValueList, args ast.Arguments) {
+	seen := make(map[string]bool, len(args))
+	for _, arg := range args {
+		if seen[arg.Name] {
+			v.errorf(arg.Node, "there can be only one argument named \"%s\" on %s", arg.Name, coord)
+		}
+		seen[arg.Name] = true
+
+		argDef := defs.Get(arg.Name)
+		if argDef == nil {
+			v.errorf(arg.Node, "unknown argument \"%s\" on %s", arg.Name, coord)
+			v.validateValue(def, arg.Value, nil, false)
+			continue
+		}
+		v.validateValue(def, arg.Value, argDef.Type, argDef.DefaultValue != nil)
+	}
+
+	for _, argDef := range defs {
+		nn, ok := argDef.Type.(*schema.NonNull)
+		if !ok || argDef.DefaultValue != nil {
+			continue
+		}
+		if !seen[argDef.Name] {
+			v.errorf(n, "argument \"%s\" of type \"%s\" is required on %s but not provided", argDef.Name, nn, coord)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package validation_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/parser"
+	"github.com/dianelooney/graphql/schema"
+	"github.com/dianelooney/graphql/validation"
+)
+
+const testSchema = `
+type Query {
+	dog: Dog
+	pet: Pet
+	search(term: String!, limit: Int = 10): [SearchResult]
+	findDog(filter: DogFilter): Dog
+}
+type Mutation { bark: Boolean }
+type Subscription { newDog: Dog newCat: Cat }
+interface Pet { name: String }
+enum Size { SMALL LARGE }
+type Dog implements Pet {
+	name: String
+	nickname: String
+	barks: Boolean
+	size: Size
+	owner: Human
+	isHouseTrained(atOtherHomes: Boolean): Boolean
+}
+type Cat implements Pet { name: String meows: Boolean }
+type Human { name: String }
+union SearchResult = Dog | Cat
+input DogFilter { size: Size! name: String }
+directive @tag(name: String) repeatable on FIELD
+`
+
+func parse(t *testing.T, src string) ast.Document {
+	p := parser.Parser{}
+	p.Init([]byte(src))
+	doc := p.Parse()
+	for _, err := range p.Errors() {
+		t.Fatalf("Error parsing %s: %v", src, err)
+	}
+	return doc
+}
+
+func testSchemaModel(t *testing.T) *schema.Schema {
+	s, errs := schema.Build(parse(t, testSchema))
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestValidDocuments(t *testing.T) {
+	s := testSchemaModel(t)
+	docs := []string{
+		`{ dog { name ... on Dog { barks } } }`,
+		`{ dog @tag(name: "a") @tag(name: "b") { name } }`,
+		`query Q { pet { __typename name ... on Dog { size } ... on Cat { meows } } }`,
+		`{ search(term: "x") { ... on Dog { name } ...CatFields } } fragment CatFields on Cat { name }`,
+		`{ findDog(filter: {size: LARGE}) { isHouseTrained(atOtherHomes: true) } }`,
+		`{ dog { name } dog { name } }`,
+		`{ pet { ... on Dog { name: nickname } ... on Cat { name } } }`,
+		`{ dog @include(if: true) { name @skip(if: false) } }`,
+		`mutation { bark }`,
+		`subscription { newDog { name } }`,
+	}
+	for _, src := range docs {
+		for _, err := range validation.Validate(s, parse(t, src)) {
+			t.Errorf("Validate(%s) returned %v", src, err)
+		}
+	}
+}
+
+func TestInvalidDocuments(t *testing.T) {
+	s := testSchemaModel(t)
+	tests := map[string]string{
+		`{ dog { name } } type T { x: Int }`:                            "the T definition is not executable",
+		`{ dog { name } } query Q { dog { name } }`:                     "an anonymous operation must be the only defined operation",
+		`subscription { newDog { name } newCat { name } }`:              "must select only one top level field",
+		`{ dog { meows } }`:                                             "cannot query field \"meows\" on type \"Dog\"",
+		`{ dog { name: nickname name } }`:                               "fields \"name\" conflict because \"nickname\" and \"name\" are different fields",
+		`{ dog { isHouseTrained(atOtherHomes: true) isHouseTrained } }`: "they have differing arguments",
+		`{ pet { ... on Dog { x: size } ... on Cat { x: name } } }`:     "they return conflicting types \"Size\" and \"String\"",
+		`{ dog }`:                "must have a selection of subfields",
+		`{ dog { name { x } } }`: "must not have a selection",
+		`{ dog { isHouseTrained(fromDog: true) } }`:                               "unknown argument \"fromDog\"",
+		`{ search { __typename } }`:                                               "argument \"term\" of type \"String!\" is required",
+		`{ dog { ... on Unknown { name } } }`:                                     "cannot condition on unknown type \"Unknown\"",
+		`{ dog { ...F } } fragment F on Size { name }`:                            "cannot condition on non composite type \"Size\"",
+		`{ dog { name } } fragment F on Dog { name }`:                             "fragment \"F\" is never used",
+		`{ dog { ...Missing } }`:                                                  "unknown fragment \"Missing\"",
+		`{ dog { ...A } } fragment A on Dog { ...B } fragment B on Dog { ...A }`:  "cannot spread fragment \"A\" within itself via B, A",
+		`{ dog { ... on Cat { meows } } }`:                                        "can never be of type \"Cat\"",
+		`{ search(term: 1) { __typename } }`:                                      "expected value of type \"String!\", found an int",
+		`{ findDog(filter: {size: HUGE}) { name } }`:                              "expected value of type \"Size!\", found the enum value HUGE",
+		`{ findDog(filter: {size: LARGE, color: 1}) { name } }`:                   "field \"color\" is not defined by type \"DogFilter\"",
+		`{ findDog(filter: {name: "x"}) { name } }`:                               "field \"DogFilter.size\" of required type \"Size!\" was not provided",
+		`{ dog @unknown { name } }`:                                               "unknown directive \"@unknown\"",
+		`query @skip(if: true) { dog { name } }`:                                  "directive \"@skip\" may not be used on QUERY",
+		`{ dog @skip(if: true) @skip(if: false) { name } }`:                       "directive \"@skip\" can only be used once at this location",
+		`query Q { dog { isHouseTrained(atOtherHomes: $x) } }`:                    "variable \"$x\" is not defined by operation \"Q\"",
+		`{ dog { ...F } } fragment F on Dog { isHouseTrained(atOtherHomes: $y) }`: "variable \"$y\" is not defined by anonymous operation",
+		`query Q { dog { name } } query Q { dog { name } }`:                       "there can be only one operation named \"Q\"",
+		`{ dog { name } } { dog { name } }`:                                       "an anonymous operation must be the only defined operation",
+		`{ dog { ...F } } fragment F on Dog { name } fragment F on Dog { name }`:  "there can be only one fragment named \"F\"",
+		`{ dog { isHouseTrained(atOtherHomes: true, atOtherHomes: false) } }`:     "there can be only one argument named \"atOtherHomes\"",
+		`{ findDog(filter: {size: LARGE, size: SMALL}) { name } }`:                "there can be only one input field named \"size\"",
+	}
+	for src, expected := range tests {
+		errs := validation.Validate(s, parse(t, src))
+		found := false
+		for _, err := range errs {
+			if strings.Contains(err.Error(), expected) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected Validate(%s) to return an error containing %q, got %v", src, expected, errs)
+		}
+	}
+}
+
+// TestRepeatedFragments checks that fragments spread many times are
+// validated in reasonable time, with a chain of fragments that each spread
+// the next one twice
+func TestRepeatedFragments(t *testing.T) {
+	s, errs := schema.Build(parse(t, `type Query { q: Query a: Int }`))
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	var src strings.Builder
+	src.WriteString("{ ...F0 }\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&src, "fragment F%d on Query { q { ...F%d } q { ...F%d } }\n", i, i+1, i+1)
+	}
+	src.WriteString("fragment F30 on Query { a }\n")
+	doc := parse(t, src.String())
+
+	done := make(chan []error)
+	go func() { done <- validation.Validate(s, doc) }()
+	select {
+	case errs := <-done:
+		for _, err := range errs {
+			t.Errorf("Validate returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Validate did not return within 5s")
+	}
+}
+
+func TestRepeatedFields(t *testing.T) {
+	s := testSchemaModel(t)
+	doc := parse(t, "{ "+strings.Repeat("dog { name barks } ", 20000)+"dog { name: nickname } }")
+
+	done := make(chan []error)
+	go func() { done <- validation.Validate(s, doc) }()
+	select {
+	case errs := <-done:
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), "subfields \"name\" conflict") {
+			t.Errorf("Expected a single conflict between the name fields, got %v", errs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Validate did not return within 5s")
+	}
+}
+
+func TestErrorLocations(t *testing.T) {
+	s := testSchemaModel(t)
+	tests := []struct {
+		src       string
+		message   string
+		locations []gqlerror.Location
+	}{
+		{"{\n  dog {\n    meows\n  }\n}", "cannot query field \"meows\" on type \"Dog\"",
+			[]gqlerror.Location{{Line: 3, Column: 5}}},
+		{"{ dog { name: nickname\n  name } }", "fields \"name\" conflict",
+			[]gqlerror.Location{{Line: 1, Column: 9}, {Line: 2, Column: 3}}},
+		{"{ dog { isHouseTrained(fromDog: true) } }", "unknown argument \"fromDog\"",
+			[]gqlerror.Location{{Line: 1, Column: 24}}},
+		{"{ search { __typename } }", "argument \"term\" of type \"String!\" is required",
+			[]gqlerror.Location{{Line: 1, Column: 3}}},
+		{"{ findDog(filter: {size: HUGE}) { name } }", "found the enum value HUGE",
+			[]gqlerror.Location{{Line: 1, Column: 26}}},
+		{"{ dog @unknown { name } }", "unknown directive \"@unknown\"",
+			[]gqlerror.Location{{Line: 1, Column: 7}}},
+		{"{ dog { ...Missing } }", "unknown fragment \"Missing\"",
+			[]gqlerror.Location{{Line: 1, Column: 9}}},
+		{"{ dog { name } }\nfragment F on Dog { name }", "fragment \"F\" is never used",
+			[]gqlerror.Location{{Line: 2, Column: 1}}},
+		{"{ dog { ...A } }\nfragment A on Dog { ...B }\nfragment B on Dog { ...A }", "within itself via B, A",
+			[]gqlerror.Location{{Line: 2, Column: 21}, {Line: 3, Column: 21}}},
+		{"query Q($x: Int) {\n  dog { isHouseTrained(atOtherHomes: $x) }\n}", "variable \"$x\" of type \"Int\" used in position expecting type \"Boolean\"",
+			[]gqlerror.Location{{Line: 1, Column: 9}, {Line: 2, Column: 38}}},
+		{"query Q($x: Int) { dog { name } }", "variable \"$x\" is never used",
+			[]gqlerror.Location{{Line: 1, Column: 9}}},
+		{"query Q($a: Boolean = $b) { dog { isHouseTrained(atOtherHomes: $a) } }", "default value of variable \"$a\" must be constant, found variable \"$b\"",
+			[]gqlerror.Location{{Line: 1, Column: 23}}},
+		{"query Q($a: [Boolean] = [true, $b]) { dog { name } }", "default value of variable \"$a\" must be constant, found variable \"$b\"",
+			[]gqlerror.Location{{Line: 1, Column: 32}}},
+	}
+	for _, test := range tests {
+		errs := validation.Validate(s, parse(t, test.src))
+		found := false
+		for _, err := range errs {
+			if err, ok := err.(*gqlerror.Error); ok && strings.Contains(err.Message, test.message) {
+				found = true
+				if !reflect.DeepEqual(err.Locations, test.locations) {
+					t.Errorf("Expected %q in %s to be located at %v, got %v", test.message, test.src, test.locations, err.Locations)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected Validate(%s) to return an error containing %q, got %v", test.src, test.message, errs)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"math"
+
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// variableUsage is a variable used where a value of type Type is expected
+type variableUsage struct {
+	Name string
+	Type schema.Type
+	Node ast.Node
+
+	// HasDefault is set when the argument or input field the variable
+	// is used for has a default value of its own
+	HasDefault bool
+}
+
+// validateValue implements "Values of Correct Type", "Input Object Field Names",
+// "Input Object Field Uniqueness" and "Input Object Required Fields" for a
+// value given where t is expected
+//
+// t is nil when the expected type is not known, in which case only the
+// variables used in value are recorded
+func (v *validator) validateValue(def string, value ast.Value, t schema.Type, hasDefault bool) {
+	if value.Variable != nil {
+		v.usages[def] = append(v.usages[def], variableUsage{*value.Variable, t, value.Node, hasDefault})
+		return
+	}
+	if t == nil {
+		for _, item := range value.List {
+			v.validateValue(def, item, nil, false)
+		}
+		for _, field := range value.Object {
+			v.validateValue(def, field.Value, nil, false)
+		}
+		return
+	}
+
+	expected := t
+	if nn, ok := t.(*schema.NonNull); ok {
+		if value.IsNull {
+			v.errorf(value.Node, "expected value of type \"%s\", found null", expected)
+			return
+		}
+		t = nn.OfType
+	} else if value.IsNull {
+		return
+	}
+
+	switch t := t.(type) {
+	case *schema.List:
+		if value.List == nil {
+			v.validateValue(def, value, t.OfType, false)
+			return
+		}
+		for _, item := range value.List {
+			v.validateValue(def, item, t.OfType, false)
+		}
+	case *schema.InputObject:
+		if value.Object == nil {
+			v.errorf(value.Node, "expected value of type \"%s\", found %s", expected, describeValue(value))
+			return
+		}
+		seen := make(map[string]bool, len(value.Object))
+		for _, field := range value.Object {
+			if seen[field.Name] {
+				v.errorf(field.Node, "there can be only one input field named \"%s\"", field.Name)
+			}
+			seen[field.Name] = true
+
+			f := t.Fields.Get(field.Name)
+			if f == nil {
+				v.errorf(field.Node, "field \"%s\" is not defined by type \"%s\"", field.Name, t)
+				v.validateValue(def, field.Value, nil, false)
+				continue
+			}
+			v.validateValue(def, field.Value, f.Type, f.DefaultValue != nil)
+		}
+		for _, f := range t.Fields {
+			if _, ok := f.Type.(*schema.NonNull); !ok || f.DefaultValue != nil {
+				continue
+			}
+			if !seen[f.Name] {
+				v.errorf(value.Node, "field \"%s.%s\" of required type \"%s\" was not provided", t, f.Name, f.Type)
+			}
+		}
+	case *schema.Enum:
+		if value.Enum == nil || t.Value(*value.Enum) == nil {
+			v.errorf(value.Node, "expected value of type \"%s\", found %s", expected, describeValue(value))
+		}
+	case *schema.Scalar:
+		if !isValidScalar(t, value) {
+			v.errorf(value.Node, "expected value of type \"%s\", found %s", expected, describeValue(value))
+		}
+	}
+}
+
+// isValidScalar checks a literal against the built-in scalars
+// Custom scalars accept any literal
+func isValidScalar(t *schema.Scalar, value ast.Value) bool {
+	switch t.Name {
+	case "Int":
+		return value.Int != nil && *value.Int >= math.MinInt32 && *value.Int <= math.MaxInt32
+	case "Float":
+		return value.Int != nil || value.Float != nil
+	case "String":
+		return value.String != nil
+	case "Boolean":
+		return value.Bool != nil
+	case "ID":
+		return value.String != nil || value.Int != nil
+	}
+	return true
+}
+
+// describeValue names the kind of a literal for error messages
+func describeValue(value ast.Value) string {
+	switch {
+	case value.Int != nil:
+		return "an int"
+	case value.Float != nil:
+		return "a float"
+	case value.String != nil:
+		return "a string"
+	case value.Bool != nil:
+		return "a boolean"
+	case value.Enum != nil:
+		return "the enum value " + *value.Enum
+	case value.List != nil:
+		return "a list"
+	case value.Object != nil:
+		return "an object"
+	}
+	return "null"
+}
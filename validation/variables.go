@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"github.com/dianelooney/graphql/ast"
+	"github.com/dianelooney/graphql/gqlerror"
+	"github.com/dianelooney/graphql/schema"
+)
+
+// validateVariableDefinitions implements "Variable Uniqueness" and "Variables Are Input Types"
+func (v *validator) validateVariableDefinitions(op *ast.Operation) {
+	seen := make(map[string]bool)
+	for _, def := range op.Variables {
+		if seen[def.Name] {
+			v.errorf(def.Node, "there can be only one variable named \"$%s\"", def.Name)
+		}
+		seen[def.Name] = true
+		v.validateDirectives(operationKey(op), def.Directives, "VARIABLE_DEFINITION")
+
+		if schema.NamedTypeName(def.Type) == "" {
+			continue
+		}
+		t := v.s.TypeFromAST(def.Type)
+		if t == nil {
+			v.errorf(def.Node, "variable \"$%s\" has unknown type \"%s\"", def.Name, schema.NamedTypeName(def.Type))
+			continue
+		}
+		if !schema.IsInputType(t) {
+			v.errorf(def.Node, "variable \"$%s\" cannot be non-input type \"%s\"", def.Name, t)
+			continue
+		}
+		if def.DefaultValue != nil && v.isConstant(def, *def.DefaultValue) {
+			v.validateValue("", *def.DefaultValue, t, false)
+		}
+	}
+}
+
+// isConstant reports whether the default value of def holds no variables,
+// reporting the ones it holds
+func (v *validator) isConstant(def ast.VariableDef, value ast.Value) bool {
+	constant := true
+	ast.Inspect(&value, func(node interface{}) bool {
+		if value, ok := node.(*ast.Value); ok && value.Variable != nil {
+			v.errorf(value.Node, "default value of variable \"$%s\" must be constant, found variable \"$%s\"", def.Name, *value.Variable)
+			constant = false
+		}
+		return true
+	})
+	return constant
+}
+
+// validateVariables implements "All Variable Uses Defined", "All Variables Used"
+// and "All Variable Usages Are Allowed", following fragment spreads from each operation
+func (v *validator) validateVariables() {
+	for _, op := range v.operations() {
+		name := "anonymous operation"
+		if op.Name != nil {
+			name = "operation \"" + *op.Name + "\""
+		}
+
+		key := operationKey(op)
+		usages := v.usages[key]
+		fragments := make(map[string]bool)
+		v.reachableFragments(key, fragments)
+		for _, frag := range v.fragmentNames() {
+			if fragments[frag] {
+				usages = append(usages, v.usages[fragmentKey(frag)]...)
+			}
+		}
+
+		defs := make(map[string]ast.VariableDef)
+		for _, def := range op.Variables {
+			defs[def.Name] = def
+		}
+
+		used := make(map[string]bool)
+		for _, usage := range usages {
+			def, ok := defs[usage.Name]
+			if !ok {
+				if !used[usage.Name] {
+					v.errorf(usage.Node, "variable \"$%s\" is not defined by %s", usage.Name, name)
+				}
+				used[usage.Name] = true
+				continue
+			}
+			used[usage.Name] = true
+			v.validateVariableUsage(def, usage)
+		}
+		for _, def := range op.Variables {
+			if !used[def.Name] {
+				v.errorf(def.Node, "variable \"$%s\" is never used in %s", def.Name, name)
+			}
+		}
+	}
+}
+
+func (v *validator) validateVariableUsage(def ast.VariableDef, usage variableUsage) {
+	if usage.Type == nil {
+		return
+	}
+	varType := v.s.TypeFromAST(def.Type)
+	if varType == nil {
+		return
+	}
+
+	locType := usage.Type
+	if nn, ok := locType.(*schema.NonNull); ok {
+		if _, ok := varType.(*schema.NonNull); !ok {
+			hasDefault := def.DefaultValue != nil && !def.DefaultValue.IsNull
+			if !hasDefault && !usage.HasDefault {
+				v.errors = append(v.errors, gqlerror.ErrorfAt(locations(def.Node, usage.Node), "variable \"$%s\" of type \"%s\" used in position expecting type \"%s\"", def.Name, varType, locType))
+				return
+			}
+			locType = nn.OfType
+		}
+	}
+	if !areTypesCompatible(varType, locType) {
+		v.errors = append(v.errors, gqlerror.ErrorfAt(locations(def.Node, usage.Node), "variable \"$%s\" of type \"%s\" used in position expecting type \"%s\"", def.Name, varType, usage.Type))
+	}
+}
+
+// areTypesCompatible implements the spec's AreTypesCompatible
+func areTypesCompatible(varType, locType schema.Type) bool {
+	if nn, ok := locType.(*schema.NonNull); ok {
+		varNN, ok := varType.(*schema.NonNull)
+		if !ok {
+			return false
+		}
+		return areTypesCompatible(varNN.OfType, nn.OfType)
+	}
+	if nn, ok := varType.(*schema.NonNull); ok {
+		return areTypesCompatible(nn.OfType, locType)
+	}
+	if l, ok := locType.(*schema.List); ok {
+		varL, ok := varType.(*schema.List)
+		if !ok {
+			return false
+		}
+		return areTypesCompatible(varL.OfType, l.OfType)
+	}
+	if _, ok := varType.(*schema.List); ok {
+		return false
+	}
+	return varType == locType
+}